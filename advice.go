@@ -0,0 +1,69 @@
+package mmap
+
+// Advice is a memory usage advice.
+type Advice int
+
+const (
+	// No special treatment.
+	AdviceNormal Advice = iota
+
+	// Expect the memory pages references in sequential order.
+	AdviceSequential
+
+	// Expect the memory pages references in random order.
+	AdviceRandom
+
+	// Expect access to the memory pages in the near future.
+	AdviceWillNeed
+
+	// Do not expect access to the memory pages in the near future.
+	// Subsequent access of the private mapping memory pages will result
+	// in the reloading of the memory contents from the underlying file
+	// or zero-fill-on-demand pages for the anonymous mapping.
+	AdviceDontNeed
+
+	// The memory pages are no longer required and may be freed lazily.
+	// Applicable only for the private anonymous mapping.
+	AdviceFree
+
+	// Enable transparent huge pages for the memory pages.
+	AdviceHugePage
+
+	// Disable transparent huge pages for the memory pages.
+	AdviceNoHugePage
+
+	// Do not make the memory pages available to the child process after a fork.
+	AdviceDontFork
+
+	// Exclude the memory pages from a core dump.
+	AdviceDontDump
+
+	// Enable kernel same-page merging for the memory pages.
+	AdviceMergeable
+)
+
+// Advise advises how the mapped memory pages will be used.
+func (m *Mapping) Advise(advice Advice) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	return m.advise(m.alignedAddress, m.alignedLength, advice)
+}
+
+// AdviseRange advises how the mapped memory pages will be used
+// starting from given offset and ends after given length.
+// Actual range may be different than the specified by the reason of aligning to page size.
+// On Linux the mapping can not be resized if the advice other than AdviceWillNeed, AdviceDontNeed or AdviceFree
+// is given to a part of it, until the advice of the same kind is given to the whole mapping by Advise:
+// AdviceNormal, AdviceSequential or AdviceRandom, AdviceHugePage or AdviceNoHugePage,
+// AdviceDontFork, AdviceDontDump and AdviceMergeable respectively.
+func (m *Mapping) AdviseRange(offset int64, length uintptr, advice Advice) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	address, alignedLength, err := m.alignRange(offset, length)
+	if err != nil {
+		return err
+	}
+	return m.advise(address, alignedLength, advice)
+}
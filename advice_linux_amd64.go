@@ -0,0 +1,70 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	madvFree     = 0x8
+	madvDontDump = 0x10
+)
+
+func (m *Mapping) advise(address, length uintptr, advice Advice) error {
+	var value int
+	switch advice {
+	case AdviceNormal:
+		value = syscall.MADV_NORMAL
+	case AdviceSequential:
+		value = syscall.MADV_SEQUENTIAL
+	case AdviceRandom:
+		value = syscall.MADV_RANDOM
+	case AdviceWillNeed:
+		value = syscall.MADV_WILLNEED
+	case AdviceDontNeed:
+		value = syscall.MADV_DONTNEED
+	case AdviceFree:
+		value = madvFree
+	case AdviceHugePage:
+		value = syscall.MADV_HUGEPAGE
+	case AdviceNoHugePage:
+		value = syscall.MADV_NOHUGEPAGE
+	case AdviceDontFork:
+		value = syscall.MADV_DONTFORK
+	case AdviceDontDump:
+		value = madvDontDump
+	case AdviceMergeable:
+		value = syscall.MADV_MERGEABLE
+	default:
+		return &ErrorInvalidAdvice{Advice: advice}
+	}
+	if err := madvise(address, length, value); err != nil {
+		return os.NewSyscallError("madvise", err)
+	}
+
+	// Memory region of the mapping is split when only a part of it gets different attributes,
+	// and it is merged again when the whole mapping gets the same ones.
+	if address != m.alignedAddress || length != m.alignedLength {
+		m.split |= adviceAttribute(advice)
+	} else {
+		m.split &^= adviceAttribute(advice)
+	}
+	return nil
+}
+
+// adviceAttribute returns the bit of the memory region attribute which is changed by given advice, zero if none.
+func adviceAttribute(advice Advice) uint {
+	switch advice {
+	case AdviceNormal, AdviceSequential, AdviceRandom:
+		return 0x1
+	case AdviceHugePage, AdviceNoHugePage:
+		return 0x2
+	case AdviceDontFork:
+		return 0x4
+	case AdviceDontDump:
+		return 0x8
+	case AdviceMergeable:
+		return 0x10
+	}
+	return 0
+}
@@ -0,0 +1,32 @@
+package mmap
+
+import (
+	"os"
+	"unsafe"
+)
+
+// Only the prefetching of the memory pages is supported.
+func (m *Mapping) advise(address, length uintptr, advice Advice) error {
+	switch advice {
+	case AdviceNormal, AdviceSequential, AdviceRandom:
+		return nil
+	case AdviceWillNeed:
+		if err := procPrefetchVirtualMemory.Find(); err != nil {
+			return &ErrorUnsupported{Operation: "advise"}
+		}
+		entry := struct {
+			address uintptr
+			length  uintptr
+		}{address, length}
+		r, _, err := procPrefetchVirtualMemory.Call(currentProcess, 1, uintptr(unsafe.Pointer(&entry)), 0)
+		if r == 0 {
+			return os.NewSyscallError("PrefetchVirtualMemory", err)
+		}
+		return nil
+	case AdviceDontNeed, AdviceFree, AdviceHugePage, AdviceNoHugePage,
+		AdviceDontFork, AdviceDontDump, AdviceMergeable:
+		return &ErrorUnsupported{Operation: "advise"}
+	default:
+		return &ErrorInvalidAdvice{Advice: advice}
+	}
+}
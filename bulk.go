@@ -0,0 +1,169 @@
+package mmap
+
+import "bytes"
+
+// region returns the mapped memory starting from given offset and ends after given length
+// checking that it may be accessed with given protection.
+func (m *Mapping) region(offset int64, length uintptr, prot Protection, operation string) ([]byte, error) {
+	if m.memory == nil {
+		return nil, &ErrorClosed{}
+	}
+	if prot&ProtectionWrite != 0 && !m.writable {
+		return nil, &ErrorIllegalOperation{Operation: operation}
+	}
+	if offset < 0 || offset >= int64(len(m.memory)) {
+		return nil, &ErrorInvalidOffset{Offset: offset}
+	}
+	if length == 0 || uint64(length) > uint64(int64(len(m.memory))-offset) {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	if m.protection(offset, length)&prot != prot {
+		return nil, &ErrorIllegalOperation{Operation: operation}
+	}
+	return m.memory[offset : offset+int64(length)], nil
+}
+
+// Fill sets the mapped memory starting from given offset and ends after given length to given value.
+func (m *Mapping) Fill(offset int64, length uintptr, value byte) error {
+	memory, err := m.region(offset, length, ProtectionWrite, "fill")
+	if err != nil {
+		return err
+	}
+	return m.safely(m.safe, func() { fill(memory, value) })
+}
+
+// Move copies given length of the mapped memory starting from the source offset to the destination offset.
+// Source and destination ranges may overlap.
+func (m *Mapping) Move(dst, src int64, length uintptr) error {
+	to, err := m.region(dst, length, ProtectionWrite, "move")
+	if err != nil {
+		return err
+	}
+	from, err := m.region(src, length, ProtectionRead, "move")
+	if err != nil {
+		return err
+	}
+	return m.safely(m.safe, func() { copy(to, from) })
+}
+
+// CopyFrom copies given length of the memory of other mapping starting from the source offset
+// to the memory of this mapping starting from the destination offset.
+func (m *Mapping) CopyFrom(other *Mapping, dst, src int64, length uintptr) error {
+	to, err := m.region(dst, length, ProtectionWrite, "copy")
+	if err != nil {
+		return err
+	}
+	from, err := other.region(src, length, ProtectionRead, "copy")
+	if err != nil {
+		return err
+	}
+	if otherErr := other.safely(other.safe, func() {
+		err = m.safely(m.safe, func() { copy(to, from) })
+	}); otherErr != nil {
+		return otherErr
+	}
+	return err
+}
+
+// Compare compares given length of the mapped memory starting from given offset
+// with the memory of other mapping starting from the other offset.
+// The result is the same as of bytes.Compare.
+func (m *Mapping) Compare(offset int64, other *Mapping, otherOffset int64, length uintptr) (int, error) {
+	a, err := m.region(offset, length, ProtectionRead, "compare")
+	if err != nil {
+		return 0, err
+	}
+	b, err := other.region(otherOffset, length, ProtectionRead, "compare")
+	if err != nil {
+		return 0, err
+	}
+	result := 0
+	if otherErr := other.safely(other.safe, func() {
+		err = m.safely(m.safe, func() { result = bytes.Compare(a, b) })
+	}); otherErr != nil {
+		return 0, otherErr
+	}
+	return result, err
+}
+
+// region returns the snapshot starting from given offset relatively to the parent mapping or view address
+// and ends after given length.
+func (tx *Transaction) region(offset int64, length uintptr) ([]byte, error) {
+	if tx.snapshot == nil {
+		return nil, &ErrorTransactionClosed{}
+	}
+	if offset += tx.origin; offset < tx.offset || offset >= tx.highOffset {
+		return nil, &ErrorInvalidOffset{Offset: offset - tx.origin}
+	}
+	if length == 0 || uint64(length) > uint64(tx.highOffset-offset) {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	return tx.snapshot[offset-tx.offset : offset-tx.offset+int64(length)], nil
+}
+
+// Fill sets the snapshot starting from given offset and ends after given length to given value.
+func (tx *Transaction) Fill(offset int64, length uintptr, value byte) error {
+	snapshot, err := tx.region(offset, length)
+	if err != nil {
+		return err
+	}
+	fill(snapshot, value)
+	return nil
+}
+
+// Move copies given length of the snapshot starting from the source offset to the destination offset.
+// Source and destination ranges may overlap.
+func (tx *Transaction) Move(dst, src int64, length uintptr) error {
+	to, err := tx.region(dst, length)
+	if err != nil {
+		return err
+	}
+	from, err := tx.region(src, length)
+	if err != nil {
+		return err
+	}
+	copy(to, from)
+	return nil
+}
+
+// CopyFrom copies given length of the memory of given mapping starting from the source offset
+// to the snapshot starting from the destination offset.
+func (tx *Transaction) CopyFrom(other *Mapping, dst, src int64, length uintptr) error {
+	to, err := tx.region(dst, length)
+	if err != nil {
+		return err
+	}
+	from, err := other.region(src, length, ProtectionRead, "copy")
+	if err != nil {
+		return err
+	}
+	return other.safely(other.safe, func() { copy(to, from) })
+}
+
+// Compare compares given length of the snapshot starting from given offset
+// with the memory of given mapping starting from the other offset.
+// The result is the same as of bytes.Compare.
+func (tx *Transaction) Compare(offset int64, other *Mapping, otherOffset int64, length uintptr) (int, error) {
+	a, err := tx.region(offset, length)
+	if err != nil {
+		return 0, err
+	}
+	b, err := other.region(otherOffset, length, ProtectionRead, "compare")
+	if err != nil {
+		return 0, err
+	}
+	result := 0
+	err = other.safely(other.safe, func() { result = bytes.Compare(a, b) })
+	return result, err
+}
+
+// fill sets all the bytes of given buffer to given value.
+func fill(buf []byte, value byte) {
+	if len(buf) == 0 {
+		return
+	}
+	buf[0] = value
+	for n := 1; n < len(buf); n *= 2 {
+		copy(buf[n:], buf[:n])
+	}
+}
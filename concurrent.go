@@ -0,0 +1,107 @@
+package mmap
+
+import "sync"
+
+// SafeMapping is a mapping which is safe for concurrent use by multiple goroutines.
+// Access to the mapped memory may run concurrently,
+// while the operations which change the mapping wait for all the access in progress to finish.
+type SafeMapping struct {
+	mu      sync.RWMutex
+	mapping *Mapping
+}
+
+// NewSafeMapping returns a new mapping which is safe for concurrent use on top of given one.
+// Given mapping is owned by the returned one and must not be used directly.
+func NewSafeMapping(m *Mapping) *SafeMapping {
+	return &SafeMapping{mapping: m}
+}
+
+// Length returns the mapped memory length in bytes or zero if the mapping is closed.
+func (s *SafeMapping) Length() uintptr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.mapping == nil {
+		return 0
+	}
+	return s.mapping.Length()
+}
+
+// Read reads len(buf) bytes at given offset from the mapped memory.
+// Implementation of io.ReaderAt.
+func (s *SafeMapping) ReadAt(buf []byte, offset int64) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.mapping == nil {
+		return 0, &ErrorClosed{}
+	}
+	return s.mapping.ReadAt(buf, offset)
+}
+
+// Write writes len(buf) bytes at given offset to the mapped memory.
+// Concurrent writes of the same memory are not ordered.
+// Implementation of io.WriterAt.
+func (s *SafeMapping) WriteAt(buf []byte, offset int64) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.mapping == nil {
+		return 0, &ErrorClosed{}
+	}
+	return s.mapping.WriteAt(buf, offset)
+}
+
+// Do calls given function with the underlying mapping while it is guaranteed not to be changed or closed.
+// Function may access the mapped memory concurrently with other goroutines,
+// but it must not change the mapping or retain it or its memory after the return.
+func (s *SafeMapping) Do(fn func(m *Mapping) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.mapping == nil {
+		return &ErrorClosed{}
+	}
+	return fn(s.mapping)
+}
+
+// DoExclusive calls given function with the underlying mapping while no other goroutine accesses it.
+// Function may change the mapping, but it must not close it or retain it or its memory after the return.
+func (s *SafeMapping) DoExclusive(fn func(m *Mapping) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mapping == nil {
+		return &ErrorClosed{}
+	}
+	return fn(s.mapping)
+}
+
+// Sync synchronizes the mapping with the underlying file.
+func (s *SafeMapping) Sync() error {
+	return s.Do(func(m *Mapping) error {
+		return m.Sync()
+	})
+}
+
+// Resize changes the mapped memory length when no other goroutine accesses the mapping.
+// See Mapping.Resize for details.
+func (s *SafeMapping) Resize(length uintptr) (bool, error) {
+	moved := false
+	err := s.DoExclusive(func(m *Mapping) error {
+		var err error
+		moved, err = m.Resize(length)
+		return err
+	})
+	return moved, err
+}
+
+// Close waits for all the access in progress to finish and closes the underlying mapping.
+// All the subsequent operations return ErrorClosed unless the mapping fails to be closed.
+func (s *SafeMapping) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mapping == nil {
+		return &ErrorClosed{}
+	}
+	if err := s.mapping.Close(); err != nil {
+		return err
+	}
+	s.mapping = nil
+	return nil
+}
@@ -0,0 +1,166 @@
+package mmap
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// ReadWriterAt is the interface that groups the basic io.ReadAt and io.WriteAt methods.
+type ReadWriterAt interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// Cursor is a seekable stream over the range of the mapping, transaction or any other ReadWriterAt.
+// Cursor tracks its own position relatively to the range start and never reads or writes beyond the range end.
+// Implementation of io.Reader, io.Writer, io.Seeker, io.ByteScanner, io.ByteWriter and io.RuneScanner.
+type Cursor struct {
+	buf      ReadWriterAt
+	offset   int64
+	length   int64
+	position int64
+	lastRead int
+}
+
+// NewCursor returns a new cursor over the range of given buffer starting from given offset and ends after given length.
+func NewCursor(buf ReadWriterAt, offset int64, length uintptr) *Cursor {
+	return &Cursor{
+		buf:      buf,
+		offset:   offset,
+		length:   int64(length),
+		lastRead: -1,
+	}
+}
+
+// Cursor returns a new cursor over the whole mapped memory.
+// Cursor does not follow the later changes of the mapping length.
+func (m *Mapping) Cursor() *Cursor {
+	return NewCursor(m, 0, m.Length())
+}
+
+// Cursor returns a new cursor over the transaction snapshot.
+func (tx *Transaction) Cursor() *Cursor {
+	return NewCursor(tx, tx.Offset(), tx.Length())
+}
+
+// Read reads up to len(buf) bytes from the current position.
+// Implementation of io.Reader.
+func (c *Cursor) Read(buf []byte) (int, error) {
+	c.lastRead = -1
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	if c.position >= c.length {
+		return 0, io.EOF
+	}
+	if remaining := c.length - c.position; int64(len(buf)) > remaining {
+		buf = buf[:remaining]
+	}
+	n, err := c.buf.ReadAt(buf, c.offset+c.position)
+	c.position += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Write writes len(buf) bytes at the current position.
+// If the range end is reached io.ErrShortWrite is returned, the range is never extended.
+// Implementation of io.Writer.
+func (c *Cursor) Write(buf []byte) (int, error) {
+	c.lastRead = -1
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	if c.position >= c.length {
+		return 0, io.ErrShortWrite
+	}
+	short := false
+	if remaining := c.length - c.position; int64(len(buf)) > remaining {
+		buf = buf[:remaining]
+		short = true
+	}
+	n, err := c.buf.WriteAt(buf, c.offset+c.position)
+	c.position += int64(n)
+	if err == nil && short {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+// Seek sets the position for the next read or write.
+// Position may be set beyond the range end, so that the next read returns io.EOF.
+// Implementation of io.Seeker.
+func (c *Cursor) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		// NOOP
+	case io.SeekCurrent:
+		offset += c.position
+	case io.SeekEnd:
+		offset += c.length
+	default:
+		return c.position, &ErrorIllegalOperation{Operation: "seek"}
+	}
+	if offset < 0 {
+		return c.position, &ErrorInvalidOffset{Offset: offset}
+	}
+	c.lastRead = -1
+	c.position = offset
+	return c.position, nil
+}
+
+// ReadByte reads a single byte from the current position.
+// Implementation of io.ByteReader.
+func (c *Cursor) ReadByte() (byte, error) {
+	var buf [1]byte
+	if _, err := c.Read(buf[:]); err != nil {
+		return 0, err
+	}
+	c.lastRead = 1
+	return buf[0], nil
+}
+
+// UnreadByte moves the position back by one byte after the successful read.
+// Implementation of io.ByteScanner.
+func (c *Cursor) UnreadByte() error {
+	if c.lastRead < 0 {
+		return &ErrorIllegalOperation{Operation: "unread"}
+	}
+	c.position--
+	c.lastRead = -1
+	return nil
+}
+
+// WriteByte writes a single byte at the current position.
+// Implementation of io.ByteWriter.
+func (c *Cursor) WriteByte(b byte) error {
+	_, err := c.Write([]byte{b})
+	return err
+}
+
+// ReadRune reads a single UTF-8 encoded character from the current position.
+// Invalid encoding is returned as utf8.RuneError of one byte size.
+// Implementation of io.RuneReader.
+func (c *Cursor) ReadRune() (rune, int, error) {
+	var buf [utf8.UTFMax]byte
+	n, err := c.Read(buf[:])
+	if n == 0 {
+		return 0, 0, err
+	}
+	r, size := utf8.DecodeRune(buf[:n])
+	c.position -= int64(n - size)
+	c.lastRead = size
+	return r, size, nil
+}
+
+// UnreadRune moves the position back by the size of the character read by the last ReadRune.
+// Implementation of io.RuneScanner.
+func (c *Cursor) UnreadRune() error {
+	if c.lastRead < 0 {
+		return &ErrorIllegalOperation{Operation: "unread"}
+	}
+	c.position -= int64(c.lastRead)
+	c.lastRead = -1
+	return nil
+}
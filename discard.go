@@ -0,0 +1,100 @@
+package mmap
+
+// Discard zeroes the mapped memory starting from given offset and ends after given length
+// and releases the storage of the memory pages which are entirely contained in the range if possible:
+// holes are punched in the underlying file of the mapping in the ModeReadWrite mode,
+// memory of the anonymous mappings is returned to the system.
+// Memory pages which are partially contained in the range and the memory of the private file mappings are just zeroed.
+func (m *Mapping) Discard(offset int64, length uintptr) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	if !m.writable || m.sealed {
+		return &ErrorIllegalOperation{Operation: "discard"}
+	}
+	if _, _, err := m.alignRange(offset, length); err != nil {
+		return err
+	}
+	if m.protection(offset, length)&ProtectionWrite == 0 {
+		return &ErrorIllegalOperation{Operation: "discard"}
+	}
+	low := m.address + uintptr(offset)
+	high := low + length
+	pagesLow := (low + m.pageSize - 1) &^ (m.pageSize - 1)
+	pagesHigh := high &^ (m.pageSize - 1)
+	if pagesLow >= pagesHigh {
+		return m.zero(low, length)
+	}
+	released, err := m.discard(pagesLow, pagesHigh-pagesLow)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return m.zero(low, length)
+	}
+	if err := m.zero(low, pagesLow-low); err != nil {
+		return err
+	}
+	return m.zero(pagesHigh, high-pagesHigh)
+}
+
+// zero zeroes the mapped memory of given address and length.
+func (m *Mapping) zero(address, length uintptr) error {
+	if length == 0 {
+		return nil
+	}
+	memory := byteSlice(address, length)
+	return m.safely(m.safe, func() { fill(memory, 0) })
+}
+
+// HoleIterator iterates over the holes of the mapped file,
+// which are the ranges of the file without the allocated storage.
+type HoleIterator struct {
+	mapping  *Mapping
+	position int64
+	offset   int64
+	length   uintptr
+	err      error
+}
+
+// Holes returns a new iterator over the holes of the mapped file.
+// Offsets of the holes are relative to the mapping address.
+func (m *Mapping) Holes() *HoleIterator {
+	return &HoleIterator{mapping: m}
+}
+
+// Next advances the iterator to the next hole which is then available through the Offset and Length.
+// It returns false when there are no more holes or an error occurred.
+func (it *HoleIterator) Next() bool {
+	if it.err != nil || it.mapping == nil {
+		return false
+	}
+	if it.mapping.memory == nil {
+		it.err = &ErrorClosed{}
+		return false
+	}
+	offset, length, err := it.mapping.nextHole(it.position)
+	if err != nil || length == 0 {
+		it.err = err
+		it.mapping = nil
+		return false
+	}
+	it.offset, it.length = offset, length
+	it.position = offset + int64(length)
+	return true
+}
+
+// Offset returns the starting offset of the current hole.
+func (it *HoleIterator) Offset() int64 {
+	return it.offset
+}
+
+// Length returns the length of the current hole in bytes.
+func (it *HoleIterator) Length() uintptr {
+	return it.length
+}
+
+// Err returns the error which occurred during the iteration.
+func (it *HoleIterator) Err() error {
+	return it.err
+}
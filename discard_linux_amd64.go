@@ -0,0 +1,94 @@
+package mmap
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+const (
+	fallocKeepSize  = 0x1
+	fallocPunchHole = 0x2
+	seekData        = 3
+	seekHole        = 4
+)
+
+func fallocate(fd uintptr, mode int, offset, length int64) error {
+	_, _, err := syscall.Syscall6(syscall.SYS_FALLOCATE, fd, uintptr(mode), uintptr(offset), uintptr(length), 0, 0)
+	if err != 0 {
+		return errno(err)
+	}
+	return nil
+}
+
+// Holes are punched in the shared file,
+// the anonymous memory is removed or dropped to be zero filled on the next access.
+// Memory of the private file mapping can not be released because it is filled by the file contents.
+func (m *Mapping) discard(address, length uintptr) (bool, error) {
+	if m.file != nil {
+		if m.mode != ModeReadWrite {
+			return false, nil
+		}
+		fileOffset := m.offset + int64(address-m.alignedAddress)
+		err := fallocate(m.file.Fd(), fallocPunchHole|fallocKeepSize, fileOffset, int64(length))
+		if err == nil {
+			return true, nil
+		}
+		if err == syscall.EOPNOTSUPP || err == syscall.ENOSYS {
+			return false, nil
+		}
+		return false, os.NewSyscallError("fallocate", err)
+	}
+	advice := syscall.MADV_DONTNEED
+	if m.mode == ModeReadWrite {
+		advice = syscall.MADV_REMOVE
+	}
+	if err := madvise(address, length, advice); err != nil {
+		// Locked memory pages can not be dropped.
+		if err == syscall.EINVAL {
+			return false, nil
+		}
+		return false, os.NewSyscallError("madvise", err)
+	}
+	return true, nil
+}
+
+// nextHole returns the next hole of the mapped file starting from given offset relatively to the mapping address.
+// Zero length is returned if there are no more holes.
+func (m *Mapping) nextHole(offset int64) (int64, uintptr, error) {
+	if m.file == nil {
+		return 0, 0, &ErrorIllegalOperation{Operation: "holes"}
+	}
+	start := m.offset + int64(m.address-m.alignedAddress)
+	end := start + int64(len(m.memory))
+
+	// File position is shared with the duplicated descriptor, so it must be kept.
+	fd := int(m.file.Fd())
+	position, err := syscall.Seek(fd, 0, io.SeekCurrent)
+	if err != nil {
+		return 0, 0, os.NewSyscallError("lseek", err)
+	}
+	defer syscall.Seek(fd, position, io.SeekStart)
+
+	hole, err := syscall.Seek(fd, start+offset, seekHole)
+	if err != nil {
+		if err == syscall.ENXIO {
+			return 0, 0, nil
+		}
+		return 0, 0, os.NewSyscallError("lseek", err)
+	}
+	if hole >= end {
+		return 0, 0, nil
+	}
+	data, err := syscall.Seek(fd, hole, seekData)
+	if err != nil {
+		if err != syscall.ENXIO {
+			return 0, 0, os.NewSyscallError("lseek", err)
+		}
+		data = end
+	}
+	if data > end {
+		data = end
+	}
+	return hole - start, uintptr(data - hole), nil
+}
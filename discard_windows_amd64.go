@@ -0,0 +1,11 @@
+package mmap
+
+// Storage can not be released, so the memory is just zeroed.
+func (m *Mapping) discard(address, length uintptr) (bool, error) {
+	return false, nil
+}
+
+// Holes of the mapped file can not be queried.
+func (m *Mapping) nextHole(offset int64) (int64, uintptr, error) {
+	return 0, 0, &ErrorUnsupported{Operation: "holes"}
+}
@@ -1,6 +1,24 @@
 package mmap
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+)
+
+// ErrorBeyondEOF is an error which returns when given range of the regular file extends beyond its end.
+type ErrorBeyondEOF struct {
+	// Offset specifies given offset.
+	Offset int64
+	// Length specifies given length.
+	Length uintptr
+	// Size specifies the file size.
+	Size int64
+}
+
+// Implementation of the error interface.
+func (err *ErrorBeyondEOF) Error() string {
+	return fmt.Sprintf("mmap: range of %d bytes at offset %d is beyond the end of file of %d bytes", err.Length, err.Offset, err.Size)
+}
 
 // ErrorClosed is an error which returns when tries to access the closed mapping.
 type ErrorClosed struct{}
@@ -10,6 +28,30 @@ func (err *ErrorClosed) Error() string {
 	return "mmap: mapping closed"
 }
 
+// ErrorFault is an error which returns when the mapped memory access faults.
+type ErrorFault struct {
+	// Offset specifies the faulting offset relatively to the mapping address.
+	Offset int64
+}
+
+// Implementation of the error interface.
+func (err *ErrorFault) Error() string {
+	return fmt.Sprintf("mmap: memory access fault at offset %d", err.Offset)
+}
+
+// ErrorHugePages is an error which returns when the huge pages can not be used for the mapping.
+type ErrorHugePages struct {
+	// PageSize specifies the requested huge page size.
+	PageSize uintptr
+	// Err specifies the underlying system error.
+	Err error
+}
+
+// Implementation of the error interface.
+func (err *ErrorHugePages) Error() string {
+	return fmt.Sprintf("mmap: huge pages of %d bytes are not available (%v)", err.PageSize, err.Err)
+}
+
 // ErrorIllegalOperation is an error which returns when tries to execute illegal operation for the mapping.
 type ErrorIllegalOperation struct {
 	// Operation specifies the operation name.
@@ -21,6 +63,28 @@ func (err *ErrorIllegalOperation) Error() string {
 	return fmt.Sprintf("mmap: illegal operation (%s)", err.Operation)
 }
 
+// ErrorInvalidAdvice is an error which returns when given memory usage advice is invalid.
+type ErrorInvalidAdvice struct {
+	// Advice specifies given memory usage advice.
+	Advice Advice
+}
+
+// Implementation of the error interface.
+func (err *ErrorInvalidAdvice) Error() string {
+	return fmt.Sprintf("mmap: invalid advice 0x%x", err.Advice)
+}
+
+// ErrorInvalidFlags is an error which returns when given mapping flags are invalid.
+type ErrorInvalidFlags struct {
+	// Flags specifies given mapping flags.
+	Flags Flag
+}
+
+// Implementation of the error interface.
+func (err *ErrorInvalidFlags) Error() string {
+	return fmt.Sprintf("mmap: invalid flags 0x%x", err.Flags)
+}
+
 // ErrorInvalidLength is an error which returns when given length is invalid.
 type ErrorInvalidLength struct {
 	// Length specifies given length.
@@ -43,6 +107,17 @@ func (err *ErrorInvalidMode) Error() string {
 	return fmt.Sprintf("mmap: invalid mode 0x%x", err.Mode)
 }
 
+// ErrorInvalidName is an error which returns when given name of the shared memory object is invalid.
+type ErrorInvalidName struct {
+	// Name specifies given name.
+	Name string
+}
+
+// Implementation of the error interface.
+func (err *ErrorInvalidName) Error() string {
+	return fmt.Sprintf("mmap: invalid name %q", err.Name)
+}
+
 // ErrorInvalidOffset is an error which returns when given offset is invalid.
 type ErrorInvalidOffset struct {
 	// Offset specifies given offset.
@@ -54,6 +129,17 @@ func (err *ErrorInvalidOffset) Error() string {
 	return fmt.Sprintf("mmap: invalid offset 0x%x", err.Offset)
 }
 
+// ErrorInvalidOption is an error which returns when given mapping option is invalid or conflicts with other ones.
+type ErrorInvalidOption struct {
+	// Option specifies the option name.
+	Option string
+}
+
+// Implementation of the error interface.
+func (err *ErrorInvalidOption) Error() string {
+	return fmt.Sprintf("mmap: invalid option (%s)", err.Option)
+}
+
 // ErrorLocked is an error which returns when the mapping memory pages were already locked.
 type ErrorLocked struct{}
 
@@ -62,6 +148,21 @@ func (err *ErrorLocked) Error() string {
 	return "mmap: mapping locked"
 }
 
+// ErrorOverlap is an error which returns when given range of the reservation is already used by another mapping.
+type ErrorOverlap struct {
+	// Offset specifies given offset within the reservation.
+	Offset uintptr
+	// Length specifies given length.
+	Length uintptr
+	// Address specifies the address of the memory which is already used.
+	Address uintptr
+}
+
+// Implementation of the error interface.
+func (err *ErrorOverlap) Error() string {
+	return fmt.Sprintf("mmap: range of %d bytes at offset %d overlaps the memory in use at 0x%x", err.Length, err.Offset, err.Address)
+}
+
 // ErrorPartialCommit is an error which returns when the transaction was committed partially.
 type ErrorPartialCommit struct {
 	// NumBytes specifies the number of bytes were committed.
@@ -88,3 +189,25 @@ type ErrorUnlocked struct{}
 func (err *ErrorUnlocked) Error() string {
 	return "mmap: mapping unlocked"
 }
+
+// ErrorUnmappable is an error which returns when the file of given type can not be mapped.
+type ErrorUnmappable struct {
+	// Mode specifies the file mode.
+	Mode os.FileMode
+}
+
+// Implementation of the error interface.
+func (err *ErrorUnmappable) Error() string {
+	return fmt.Sprintf("mmap: file of mode %v can not be mapped", err.Mode)
+}
+
+// ErrorUnsupported is an error which returns when the operation is not supported by the platform.
+type ErrorUnsupported struct {
+	// Operation specifies the operation name.
+	Operation string
+}
+
+// Implementation of the error interface.
+func (err *ErrorUnsupported) Error() string {
+	return fmt.Sprintf("mmap: unsupported operation (%s)", err.Operation)
+}
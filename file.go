@@ -0,0 +1,85 @@
+package mmap
+
+import "os"
+
+// Open opens the named file and maps it into the memory.
+// The file is opened for reading and writing in the ModeReadWrite mode and read-only otherwise.
+// The whole file is mapped unless another range is specified by WithRange option.
+// The file is owned by the returned mapping and is closed when the mapping is closed.
+func Open(name string, mode Mode, opts ...Option) (*Mapping, error) {
+	flag := os.O_RDONLY
+	if mode == ModeReadWrite {
+		flag = os.O_RDWR
+	}
+	f, err := os.OpenFile(name, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+	m, err := openFile(f, mode, makeOptions(opts))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Create creates the named file of given size and maps it into the memory in the ModeReadWrite mode.
+// If the file already exists, it is resized to given size.
+// The whole file is mapped unless another range is specified by WithRange option.
+// The file is owned by the returned mapping and is closed when the mapping is closed.
+func Create(name string, size int64, perm os.FileMode, opts ...Option) (*Mapping, error) {
+	if size <= 0 {
+		return nil, &ErrorInvalidLength{Length: uintptr(size)}
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	m, err := openFile(f, ModeReadWrite, makeOptions(opts))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// openFile maps given file into the memory using given options.
+// Given mode takes precedence over WithMode option.
+func openFile(f *os.File, mode Mode, o *options) (*Mapping, error) {
+	o.mode = mode
+	return newMapping(f, o)
+}
+
+// mappedLength returns the length of given file range to map.
+// Zero length means that the file is mapped from given offset up to its end.
+// Range of the regular file must not exceed its size, pipes, sockets and directories can not be mapped at all.
+func mappedLength(f *os.File, offset int64, length uintptr) (uintptr, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Mode()&(os.ModeDir|os.ModeNamedPipe|os.ModeSocket) != 0 {
+		return 0, &ErrorUnmappable{Mode: info.Mode()}
+	}
+	if !info.Mode().IsRegular() {
+		if length == 0 {
+			return 0, &ErrorInvalidLength{Length: length}
+		}
+		return length, nil
+	}
+	size := info.Size()
+	if length == 0 {
+		if offset >= size {
+			return 0, &ErrorInvalidOffset{Offset: offset}
+		}
+		return uintptr(size - offset), nil
+	}
+	if offset > size || uint64(length) > uint64(size-offset) {
+		return 0, &ErrorBeyondEOF{Offset: offset, Length: length, Size: size}
+	}
+	return length, nil
+}
@@ -0,0 +1,102 @@
+package mmap
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	hugetlbfsMagic = 0x958458f6
+	mapHugeShift   = 26
+)
+
+// HugePageSizes returns the sizes of the huge pages which are supported by the system in ascending order.
+func HugePageSizes() ([]uintptr, error) {
+	infos, err := ioutil.ReadDir("/sys/kernel/mm/hugepages")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &ErrorUnsupported{Operation: "huge pages"}
+		}
+		return nil, err
+	}
+	var sizes []uintptr
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, "hugepages-") || !strings.HasSuffix(name, "kB") {
+			continue
+		}
+		kb, err := strconv.ParseUint(name[len("hugepages-"):len(name)-len("kB")], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, uintptr(kb<<10))
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	return sizes, nil
+}
+
+// DefaultHugePageSize returns the default size of the huge pages.
+func DefaultHugePageSize() (uintptr, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 3 && string(fields[0]) == "Hugepagesize:" {
+			kb, err := strconv.ParseUint(string(fields[1]), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return uintptr(kb << 10), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, &ErrorUnsupported{Operation: "huge pages"}
+}
+
+// flagHugePageSize returns the size of the huge pages which is requested by given flags.
+func flagHugePageSize(flags Flag) (uintptr, error) {
+	switch flags & (FlagHugePages2MB | FlagHugePages1GB) {
+	case FlagHugePages:
+		return DefaultHugePageSize()
+	case FlagHugePages2MB:
+		return 1 << 21, nil
+	case FlagHugePages1GB:
+		return 1 << 30, nil
+	}
+	return 0, &ErrorInvalidFlags{Flags: flags}
+}
+
+// fileHugePageSize returns the size of the huge pages of given file
+// if it is on the hugetlbfs or zero otherwise.
+func fileHugePageSize(f *os.File) (uintptr, error) {
+	var statfs syscall.Statfs_t
+	if err := syscall.Fstatfs(int(f.Fd()), &statfs); err != nil {
+		return 0, os.NewSyscallError("fstatfs", err)
+	}
+	if uint32(statfs.Type) != hugetlbfsMagic {
+		return 0, nil
+	}
+	return uintptr(statfs.Bsize), nil
+}
+
+// hugePageShift returns the mapping flags which select the huge pages of given size.
+func hugePageShift(size uintptr) int {
+	shift := 0
+	for size > 1 {
+		size >>= 1
+		shift++
+	}
+	return shift << mapHugeShift
+}
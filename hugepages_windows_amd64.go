@@ -0,0 +1,13 @@
+package mmap
+
+// HugePageSizes returns the sizes of the huge pages which are supported by the system in ascending order.
+// It is not supported on Windows.
+func HugePageSizes() ([]uintptr, error) {
+	return nil, &ErrorUnsupported{Operation: "huge pages"}
+}
+
+// DefaultHugePageSize returns the default size of the huge pages.
+// It is not supported on Windows.
+func DefaultHugePageSize() (uintptr, error) {
+	return 0, &ErrorUnsupported{Operation: "huge pages"}
+}
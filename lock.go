@@ -0,0 +1,110 @@
+package mmap
+
+// LockFlag is a memory locking flags.
+type LockFlag int
+
+const (
+	// Lock the memory pages only when they are touched instead of all at once.
+	LockFlagOnFault LockFlag = 0x1
+)
+
+// LockLimit is a memory locking limit of the current process.
+type LockLimit struct {
+	// Current specifies the soft limit in bytes.
+	Current uint64
+	// Max specifies the hard limit in bytes.
+	Max uint64
+	// Used specifies the number of bytes which are locked at the moment.
+	Used uint64
+}
+
+// lockedRange is a range of the locked memory pages relatively to the aligned mapping address.
+type lockedRange struct {
+	offset uintptr
+	length uintptr
+	flags  LockFlag
+}
+
+// LockRange locks the mapped memory pages starting from given offset and ends after given length.
+// Actual range may be different than the specified by the reason of aligning to page size.
+// Several ranges may be locked simultaneously, but they must not share the memory pages.
+// See Lock for details.
+func (m *Mapping) LockRange(offset int64, length uintptr, flags LockFlag) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	if m.locked {
+		return &ErrorLocked{}
+	}
+	address, alignedLength, err := m.alignRange(offset, length)
+	if err != nil {
+		return err
+	}
+	r := lockedRange{offset: address - m.alignedAddress, length: alignedLength, flags: flags}
+	for _, l := range m.lockedRanges {
+		if r.offset < l.offset+l.length && l.offset < r.offset+r.length {
+			return &ErrorLocked{}
+		}
+	}
+	if err := m.lock(address, alignedLength, flags); err != nil {
+		return err
+	}
+	m.lockedRanges = append(m.lockedRanges, r)
+	return nil
+}
+
+// UnlockRange unlocks the mapped memory pages
+// which were locked by LockRange with the same offset and length.
+func (m *Mapping) UnlockRange(offset int64, length uintptr) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	address, alignedLength, err := m.alignRange(offset, length)
+	if err != nil {
+		return err
+	}
+	rangeOffset := address - m.alignedAddress
+	for i, l := range m.lockedRanges {
+		if l.offset == rangeOffset && l.length == alignedLength {
+			if err := m.unlock(address, alignedLength); err != nil {
+				return err
+			}
+			m.lockedRanges = append(m.lockedRanges[:i], m.lockedRanges[i+1:]...)
+			return nil
+		}
+	}
+	return &ErrorUnlocked{}
+}
+
+// unlockRanges unlocks all the mapped memory pages which were locked by LockRange.
+// Unlocked ranges are kept to be locked again by relockRanges.
+func (m *Mapping) unlockRanges() error {
+	for _, l := range m.lockedRanges {
+		if err := m.unlock(m.alignedAddress+l.offset, l.length); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relockRanges locks again the memory pages which were locked by LockRange after the mapping is remapped.
+// Memory pages which are no longer mapped are forgotten.
+func (m *Mapping) relockRanges() error {
+	ranges := m.lockedRanges[:0]
+	for _, l := range m.lockedRanges {
+		if l.offset >= m.alignedLength {
+			continue
+		}
+		if l.offset+l.length > m.alignedLength {
+			l.length = m.alignedLength - l.offset
+		}
+		ranges = append(ranges, l)
+	}
+	m.lockedRanges = ranges
+	for _, l := range m.lockedRanges {
+		if err := m.lock(m.alignedAddress+l.offset, l.length, l.flags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
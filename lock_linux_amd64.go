@@ -0,0 +1,104 @@
+package mmap
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+const (
+	mlockOnFault  = 0x1
+	rlimitMemlock = 0x8
+	sysMlock2     = 325
+)
+
+func mlock2(addr, length uintptr, flags int) error {
+	_, _, err := syscall.Syscall(sysMlock2, addr, length, uintptr(flags))
+	if err != 0 {
+		return errno(err)
+	}
+	return nil
+}
+
+func (m *Mapping) lock(address, length uintptr, flags LockFlag) error {
+	if flags&LockFlagOnFault != 0 {
+		if err := mlock2(address, length, mlockOnFault); err != nil {
+			if err == syscall.ENOSYS {
+				return &ErrorUnsupported{Operation: "lock on fault"}
+			}
+			return os.NewSyscallError("mlock2", err)
+		}
+		return nil
+	}
+	if err := mlock(address, length); err != nil {
+		return os.NewSyscallError("mlock", err)
+	}
+	return nil
+}
+
+func (m *Mapping) unlock(address, length uintptr) error {
+	if err := munlock(address, length); err != nil {
+		return os.NewSyscallError("munlock", err)
+	}
+	return nil
+}
+
+// GetLockLimit returns the memory locking limit of the current process.
+// Unlimited values are reported as the maximum of uint64.
+func GetLockLimit() (*LockLimit, error) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(rlimitMemlock, &rlimit); err != nil {
+		return nil, os.NewSyscallError("getrlimit", err)
+	}
+	used, err := lockedBytes()
+	if err != nil {
+		return nil, err
+	}
+	return &LockLimit{Current: rlimit.Cur, Max: rlimit.Max, Used: used}, nil
+}
+
+// RaiseLockLimit raises the soft memory locking limit of the current process
+// to given number of bytes but not above the hard limit.
+// The limit is never lowered.
+func RaiseLockLimit(limit uint64) (*LockLimit, error) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(rlimitMemlock, &rlimit); err != nil {
+		return nil, os.NewSyscallError("getrlimit", err)
+	}
+	if limit > rlimit.Max {
+		limit = rlimit.Max
+	}
+	if limit > rlimit.Cur {
+		rlimit.Cur = limit
+		if err := syscall.Setrlimit(rlimitMemlock, &rlimit); err != nil {
+			return nil, os.NewSyscallError("setrlimit", err)
+		}
+	}
+	return GetLockLimit()
+}
+
+// lockedBytes returns the number of bytes locked by the current process.
+func lockedBytes() (uint64, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 3 && string(fields[0]) == "VmLck:" {
+			kb, err := strconv.ParseUint(string(fields[1]), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb << 10, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, &ErrorUnsupported{Operation: "locked memory accounting"}
+}
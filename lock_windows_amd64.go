@@ -0,0 +1,33 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+// Memory pages are always locked at once, so LockFlagOnFault is ignored.
+func (m *Mapping) lock(address, length uintptr, flags LockFlag) error {
+	if err := syscall.VirtualLock(address, length); err != nil {
+		return os.NewSyscallError("VirtualLock", err)
+	}
+	return nil
+}
+
+func (m *Mapping) unlock(address, length uintptr) error {
+	if err := syscall.VirtualUnlock(address, length); err != nil {
+		return os.NewSyscallError("VirtualUnlock", err)
+	}
+	return nil
+}
+
+// GetLockLimit returns the memory locking limit of the current process.
+// It is not supported on Windows where the limit is defined by the working set size.
+func GetLockLimit() (*LockLimit, error) {
+	return nil, &ErrorUnsupported{Operation: "lock limit"}
+}
+
+// RaiseLockLimit raises the soft memory locking limit of the current process.
+// It is not supported on Windows where the limit is defined by the working set size.
+func RaiseLockLimit(limit uint64) (*LockLimit, error) {
+	return nil, &ErrorUnsupported{Operation: "lock limit"}
+}
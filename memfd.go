@@ -0,0 +1,23 @@
+package mmap
+
+// FileSeal is a file sealing flags.
+// Seals restrict the operations which are allowed on the memory file for all its users.
+type FileSeal int
+
+const (
+	// Prevent further seals from being applied.
+	FileSealSeal FileSeal = 0x1
+
+	// Prevent the file from being shrunk.
+	FileSealShrink FileSeal = 0x2
+
+	// Prevent the file from being grown.
+	FileSealGrow FileSeal = 0x4
+
+	// Prevent the file contents from being modified.
+	// Can not be applied while the file is mapped for writing in the shared mode.
+	FileSealWrite FileSeal = 0x8
+
+	// Prevent the file contents from being modified except by the already existing writable mappings.
+	FileSealFutureWrite FileSeal = 0x10
+)
@@ -0,0 +1,153 @@
+package mmap
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fAddSeals       = 1033
+	fGetSeals       = 1034
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	sysMemfdCreate  = 319
+)
+
+func memfdCreate(name string, flags int) (uintptr, error) {
+	namePtr, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	result, _, errNo := syscall.Syscall(sysMemfdCreate, uintptr(unsafe.Pointer(namePtr)), uintptr(flags), 0)
+	if errNo != 0 {
+		return 0, errno(errNo)
+	}
+	return result, nil
+}
+
+func fcntl(fd uintptr, cmd, arg int) (int, error) {
+	result, _, err := syscall.Syscall(syscall.SYS_FCNTL, fd, uintptr(cmd), uintptr(arg))
+	if err != 0 {
+		return 0, errno(err)
+	}
+	return int(result), nil
+}
+
+// NewMemfd creates an anonymous memory file of given size which allows sealing and maps it into the memory
+// in the ModeReadWrite mode. Name is used only for debugging purposes and may be duplicated.
+// The whole file is mapped unless another range is specified by WithRange option.
+// The file is owned by the returned mapping and is closed when the mapping is closed.
+// See File to pass the file to other processes, which can map it using New.
+func NewMemfd(name string, size int64, opts ...Option) (*Mapping, error) {
+	if size <= 0 {
+		return nil, &ErrorInvalidLength{Length: uintptr(size)}
+	}
+	fd, err := memfdCreate(name, mfdCloexec|mfdAllowSealing)
+	if err != nil {
+		if err == syscall.ENOSYS {
+			return nil, &ErrorUnsupported{Operation: "memfd"}
+		}
+		return nil, os.NewSyscallError("memfd_create", err)
+	}
+	f := os.NewFile(fd, "memfd:"+name)
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	m, err := openFile(f, ModeReadWrite, makeOptions(opts))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// AddFileSeals applies given seals to the memory file which is created by NewMemfd.
+// FileSealWrite can not be applied while any shared mapping of the file opened for writing exists,
+// so this mapping is remapped in the ModeReadOnly mode using the file reopened read-only before,
+// and it is remapped back if the seals can not be applied.
+// Any other mapping of the file must be closed in advance.
+// Use FileSealFutureWrite to keep writing using the existing mappings.
+func (m *Mapping) AddFileSeals(seals FileSeal) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	if m.file == nil {
+		return &ErrorIllegalOperation{Operation: "seal"}
+	}
+	if seals&FileSealWrite == 0 || m.flags&syscall.MAP_SHARED == 0 {
+		return m.addFileSeals(seals)
+	}
+	if m.sealed {
+		return &ErrorIllegalOperation{Operation: "seal"}
+	}
+	f, err := os.Open("/proc/self/fd/" + strconv.Itoa(int(m.file.Fd())))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	mode, protections := m.mode, append([]protectedRange(nil), m.protections...)
+	err = m.remapFile(f.Fd(), ModeReadOnly)
+	if err == nil {
+		err = m.addFileSeals(seals)
+	}
+	if err != nil {
+		m.protections = protections
+		m.remapFile(m.file.Fd(), mode)
+		return err
+	}
+	return nil
+}
+
+// addFileSeals applies given seals to the mapped file.
+func (m *Mapping) addFileSeals(seals FileSeal) error {
+	if _, err := fcntl(m.file.Fd(), fAddSeals, int(seals)); err != nil {
+		if err == syscall.EINVAL {
+			return &ErrorUnsupported{Operation: "seal"}
+		}
+		return os.NewSyscallError("fcntl", err)
+	}
+	return nil
+}
+
+// remapFile maps the file again in place of the mapped memory pages using given file descriptor and mode.
+// Lock and protection state of the memory pages is kept as far as given mode allows,
+// advice given to the part of them is lost.
+func (m *Mapping) remapFile(fd uintptr, mode Mode) error {
+	writable := mode == ModeReadWrite
+	prot := syscall.PROT_READ
+	if writable {
+		prot |= syscall.PROT_WRITE
+	}
+	if m.executable {
+		prot |= syscall.PROT_EXEC
+	}
+	if _, err := mmap(m.alignedAddress, m.alignedLength, prot, m.flags|syscall.MAP_FIXED, fd, m.offset); err != nil {
+		return os.NewSyscallError("mmap", err)
+	}
+	m.mode, m.prot, m.writable, m.split = mode, prot, writable, 0
+	for i := range m.protections {
+		m.protections[i].prot &= m.maxProtection()
+	}
+	return m.reattribute()
+}
+
+// FileSeals returns the seals applied to the mapped file.
+func (m *Mapping) FileSeals() (FileSeal, error) {
+	if m.memory == nil {
+		return 0, &ErrorClosed{}
+	}
+	if m.file == nil {
+		return 0, &ErrorIllegalOperation{Operation: "seal"}
+	}
+	seals, err := fcntl(m.file.Fd(), fGetSeals, 0)
+	if err != nil {
+		if err == syscall.EINVAL {
+			return 0, &ErrorUnsupported{Operation: "seal"}
+		}
+		return 0, os.NewSyscallError("fcntl", err)
+	}
+	return FileSeal(seals), nil
+}
@@ -0,0 +1,16 @@
+package mmap
+
+// NewMemfd is not supported on Windows.
+func NewMemfd(name string, size int64, opts ...Option) (*Mapping, error) {
+	return nil, &ErrorUnsupported{Operation: "memfd"}
+}
+
+// AddFileSeals is not supported on Windows.
+func (m *Mapping) AddFileSeals(seals FileSeal) error {
+	return &ErrorUnsupported{Operation: "seal"}
+}
+
+// FileSeals is not supported on Windows.
+func (m *Mapping) FileSeals() (FileSeal, error) {
+	return 0, &ErrorUnsupported{Operation: "seal"}
+}
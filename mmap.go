@@ -1,8 +1,12 @@
 // Package mmap provides the cross-platform memory mapped file I/O.
-// Note than all provided tools are not thread safe.
+// Note than all provided tools are not thread safe, see SafeMapping for the concurrent use.
 package mmap
 
-import "io"
+import (
+	"io"
+	"os"
+	"unsafe"
+)
 
 // Mode is a mapping mode.
 type Mode int
@@ -31,23 +35,155 @@ type Flag int
 const (
 	// Mapped memory pages may be executed.
 	FlagExecutable Flag = 0x1
+
+	// Surround the mapped memory with inaccessible guard pages,
+	// so that any access beyond the mapping bounds faults immediately.
+	// Not supported on Windows.
+	FlagGuardPages Flag = 0x2
+
+	// Map the memory using the huge pages of the default size.
+	// The huge pages must be reserved by the system administrator,
+	// otherwise the mapping fails with ErrorHugePages.
+	// See AdviceHugePage for the transparent huge pages which do not need a reservation.
+	// Files on the hugetlbfs are always mapped using the huge pages of the filesystem.
+	// Can not be combined with FlagGuardPages.
+	// Not supported on Windows.
+	FlagHugePages Flag = 0x4
+
+	// Map the memory using the huge pages of 2MB.
+	// See FlagHugePages for details.
+	FlagHugePages2MB Flag = FlagHugePages | 0x8
+
+	// Map the memory using the huge pages of 1GB.
+	// See FlagHugePages for details.
+	FlagHugePages1GB Flag = FlagHugePages | 0x10
+
+	// Prefault the mapped memory pages, so that the subsequent access to them does not fault.
+	// See Prefetch for the warming up in the background.
+	FlagPopulate Flag = 0x20
+
+	// Turn the memory access faults into ErrorFault for ReadAt, WriteAt and the transactions,
+	// e.g. when the underlying file was truncated by another process.
+	// Requires Go 1.17 or later, see Guard for details.
+	FlagSafeAccess Flag = 0x40
+
+	// Do not reserve the swap space for the mapped memory,
+	// so that the write access may fault if there is no free memory.
+	// Can not be combined with FlagLock.
+	// Not supported on Windows.
+	FlagNoReserve Flag = 0x80
+
+	// Lock the mapped memory pages right after the mapping is created.
+	// See Lock for details.
+	FlagLock Flag = 0x100
+
+	// flagMask contains all the known flags.
+	flagMask = FlagExecutable | FlagGuardPages | FlagHugePages2MB | FlagHugePages1GB |
+		FlagPopulate | FlagSafeAccess | FlagNoReserve | FlagLock
+)
+
+// SyncFlag is a synchronization flags.
+// Zero value requests the synchronous update.
+type SyncFlag int
+
+const (
+	// Schedule the update and return immediately.
+	SyncFlagAsync SyncFlag = 0x1
+
+	// Invalidate other mappings of the same file,
+	// so that they can be updated with the fresh values just written.
+	SyncFlagInvalidate SyncFlag = 0x2
 )
 
 type internal struct {
+	mode       Mode
+	pageSize   uintptr
 	writable   bool
 	executable bool
+	safe       bool
+	sealed     bool
 	address    uintptr
 	memory     []byte
+	file       *os.File
 }
 
-// Writable returns true if the mapped memory pages may be written.
+// byteSlice converts the memory region of given address and length into a byte slice.
+func byteSlice(address, length uintptr) []byte {
+	var sliceHeader struct {
+		data uintptr
+		len  int
+		cap  int
+	}
+	sliceHeader.data = address
+	sliceHeader.len = int(length)
+	sliceHeader.cap = sliceHeader.len
+	return *(*[]byte)(unsafe.Pointer(&sliceHeader))
+}
+
+// alignRange returns the address and length of the memory pages
+// which contain the mapped memory starting from given offset and ends after given length.
+func (m *internal) alignRange(offset int64, length uintptr) (uintptr, uintptr, error) {
+	if offset < 0 || offset >= int64(len(m.memory)) {
+		return 0, 0, &ErrorInvalidOffset{Offset: offset}
+	}
+	if length == 0 || length > uintptr(int64(len(m.memory))-offset) {
+		return 0, 0, &ErrorInvalidLength{Length: length}
+	}
+	address := m.address + uintptr(offset)
+	alignedAddress := address &^ (m.pageSize - 1)
+	return alignedAddress, address - alignedAddress + length, nil
+}
+
+// span returns the length of the mapped memory which is accessed by the I/O of given buffer at given valid offset.
+func (m *internal) span(buf []byte, offset int64) uintptr {
+	if n := int64(len(m.memory)) - offset; int64(len(buf)) > n {
+		return uintptr(n)
+	}
+	return uintptr(len(buf))
+}
+
+// Writable returns true if all the mapped memory pages may be written.
+// See Protection for the state of particular memory pages.
 func (m *Mapping) Writable() bool {
-	return m.writable
+	if m.memory == nil {
+		return false
+	}
+	return m.protection(0, uintptr(len(m.memory)))&ProtectionWrite != 0
 }
 
-// Executable returns true if the mapped memory pages may be executed.
+// Executable returns true if all the mapped memory pages may be executed.
+// See Protection for the state of particular memory pages.
 func (m *Mapping) Executable() bool {
-	return m.executable
+	if m.memory == nil {
+		return false
+	}
+	return m.protection(0, uintptr(len(m.memory)))&ProtectionExecute != 0
+}
+
+// Sealed returns true if the mapping is sealed by Seal.
+func (m *Mapping) Sealed() bool {
+	return m.sealed
+}
+
+// File returns the underlying file of this mapping or nil if the mapping is anonymous.
+// The file is owned by the mapping and is closed when the mapping is closed.
+func (m *Mapping) File() *os.File {
+	return m.file
+}
+
+// Name returns the name of the underlying file as presented to Open or Create.
+// Empty string is returned if the mapping is anonymous or was created by New.
+func (m *Mapping) Name() string {
+	if m.file == nil {
+		return ""
+	}
+	return m.file.Name()
+}
+
+// PageSize returns the size of the memory pages which are used by this mapping.
+// Mapped memory is aligned by this size.
+func (m *Mapping) PageSize() uintptr {
+	return m.pageSize
 }
 
 // Address returns pointer to the mapped memory.
@@ -65,16 +201,45 @@ func (m *Mapping) Memory() []byte {
 	return m.memory
 }
 
+// Grow extends the mapped memory by given number of bytes.
+// See Resize for details.
+func (m *Mapping) Grow(delta uintptr) (bool, error) {
+	if m.memory == nil {
+		return false, &ErrorClosed{}
+	}
+	length := uintptr(len(m.memory)) + delta
+	if length < delta {
+		return false, &ErrorInvalidLength{Length: delta}
+	}
+	return m.Resize(length)
+}
+
 // Read reads len(buf) bytes at given offset from the mapped memory.
 // Implementation of io.ReaderAt.
 func (m *Mapping) ReadAt(buf []byte, offset int64) (int, error) {
+	return m.readAt(buf, offset, m.safe)
+}
+
+// SafeReadAt is the same as ReadAt but the memory access fault is always returned as ErrorFault.
+// See Guard for details.
+func (m *Mapping) SafeReadAt(buf []byte, offset int64) (int, error) {
+	return m.readAt(buf, offset, true)
+}
+
+func (m *Mapping) readAt(buf []byte, offset int64, safe bool) (int, error) {
 	if m.memory == nil {
 		return 0, &ErrorClosed{}
 	}
 	if offset < 0 || offset >= int64(len(m.memory)) {
 		return 0, &ErrorInvalidOffset{Offset: offset}
 	}
-	n := copy(buf, m.memory[offset:])
+	if m.protection(offset, m.span(buf, offset))&ProtectionRead == 0 {
+		return 0, &ErrorIllegalOperation{Operation: "read"}
+	}
+	n := 0
+	if err := m.safely(safe, func() { n = copy(buf, m.memory[offset:]) }); err != nil {
+		return n, err
+	}
 	if n < len(buf) {
 		return n, io.EOF
 	}
@@ -84,6 +249,16 @@ func (m *Mapping) ReadAt(buf []byte, offset int64) (int, error) {
 // Write writes len(buf) bytes at given offset to the mapped memory.
 // Implementation of io.WriterAt.
 func (m *Mapping) WriteAt(buf []byte, offset int64) (int, error) {
+	return m.writeAt(buf, offset, m.safe)
+}
+
+// SafeWriteAt is the same as WriteAt but the memory access fault is always returned as ErrorFault.
+// See Guard for details.
+func (m *Mapping) SafeWriteAt(buf []byte, offset int64) (int, error) {
+	return m.writeAt(buf, offset, true)
+}
+
+func (m *Mapping) writeAt(buf []byte, offset int64, safe bool) (int, error) {
 	if m.memory == nil {
 		return 0, &ErrorClosed{}
 	}
@@ -93,7 +268,13 @@ func (m *Mapping) WriteAt(buf []byte, offset int64) (int, error) {
 	if offset < 0 || offset >= int64(len(m.memory)) {
 		return 0, &ErrorInvalidOffset{Offset: offset}
 	}
-	n := copy(m.memory[offset:], buf)
+	if m.protection(offset, m.span(buf, offset))&ProtectionWrite == 0 {
+		return 0, &ErrorIllegalOperation{Operation: "write"}
+	}
+	n := 0
+	if err := m.safely(safe, func() { n = copy(m.memory[offset:], buf) }); err != nil {
+		return n, err
+	}
 	if n < len(buf) {
 		return n, io.EOF
 	}
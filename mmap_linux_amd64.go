@@ -4,11 +4,15 @@ import (
 	"os"
 	"runtime"
 	"syscall"
-	"unsafe"
 )
 
 const maxInt = int(^uint(0) >> 1)
 
+const (
+	mremapMayMove = 0x1
+	mremapFixed   = 0x2
+)
+
 func errno(err error) error {
 	if err != nil {
 		if en, ok := err.(syscall.Errno); ok && en == 0 {
@@ -30,12 +34,36 @@ func mmap(addr, length uintptr, prot, flags int, fd uintptr, offset int64) (uint
 	return result, nil
 }
 
+func mremap(oldAddr, oldLength, newLength uintptr, flags int, newAddr uintptr) (uintptr, error) {
+	result, _, err := syscall.Syscall6(syscall.SYS_MREMAP, oldAddr, oldLength, newLength, uintptr(flags), newAddr, 0)
+	if err != 0 {
+		return 0, errno(err)
+	}
+	return result, nil
+}
+
+func mprotect(addr, length uintptr, prot int) error {
+	_, _, err := syscall.Syscall(syscall.SYS_MPROTECT, addr, length, uintptr(prot))
+	if err != 0 {
+		return errno(err)
+	}
+	return nil
+}
+
+func madvise(addr, length uintptr, advice int) error {
+	_, _, err := syscall.Syscall(syscall.SYS_MADVISE, addr, length, uintptr(advice))
+	if err != 0 {
+		return errno(err)
+	}
+	return nil
+}
+
 func mlock(addr, length uintptr) error {
 	_, _, err := syscall.Syscall(syscall.SYS_MLOCK, addr, length, 0)
 	if err != 0 {
 		return errno(err)
 	}
-	return err
+	return nil
 }
 
 func munlock(addr, length uintptr) error {
@@ -46,8 +74,8 @@ func munlock(addr, length uintptr) error {
 	return nil
 }
 
-func msync(addr, length uintptr) error {
-	_, _, err := syscall.Syscall(syscall.SYS_MSYNC, addr, length, syscall.MS_SYNC)
+func msync(addr, length uintptr, flags int) error {
+	_, _, err := syscall.Syscall(syscall.SYS_MSYNC, addr, length, uintptr(flags))
 	if err != 0 {
 		return errno(err)
 	}
@@ -65,83 +93,471 @@ func munmap(addr, length uintptr) error {
 // Mapping is a mapping of the file into the memory.
 type Mapping struct {
 	internal
+	offset         int64
+	prot           int
+	flags          int
 	alignedAddress uintptr
 	alignedLength  uintptr
+	hugetlb        bool
+	guard          uintptr
 	locked         bool
+	lockedRanges   []lockedRange
+	protections    []protectedRange
+	split          uint
+	reservation    *Reservation
+	secure         bool
 }
 
 // New returns a new mapping of the file into the memory.
 // Actual offset and length may be different than the specified by the reason of aligning to page size.
+// Zero length means that the file is mapped from given offset up to its end.
+// Range of the regular file must not exceed its size, otherwise ErrorBeyondEOF is returned.
+// Given file descriptor is duplicated, so the file may be closed right after the mapping is created.
 func New(fd uintptr, offset int64, length uintptr, mode Mode, flags Flag) (*Mapping, error) {
+	return NewWithOptions(fd, offset, length, WithMode(mode), WithFlags(flags))
+}
+
+// NewWithOptions returns a new mapping of the file into the memory using given options.
+// See New for details.
+func NewWithOptions(fd uintptr, offset int64, length uintptr, opts ...Option) (*Mapping, error) {
+	o := makeOptions(opts)
+	if o.ranged {
+		return nil, &ErrorInvalidOption{Option: "range"}
+	}
+	if o.exclusive {
+		return nil, &ErrorInvalidOption{Option: "exclusive"}
+	}
+	o.offset = offset
+	o.length = length
+
+	// Separate file descriptor needed to resize the mapping after the mapped file external closing.
+	dupFd, err := syscall.Dup(int(fd))
+	if err != nil {
+		return nil, os.NewSyscallError("dup", err)
+	}
+	syscall.CloseOnExec(dupFd)
+	f := os.NewFile(uintptr(dupFd), "")
+	m, err := newMapping(f, o)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// newMapping returns a new mapping of given file into the memory.
+// Returned mapping owns the file and closes it when the mapping is closed.
+func newMapping(f *os.File, o *options) (*Mapping, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	offset, mode, flags := o.offset, o.mode, o.flags
 
 	// Using int64 (off_t) for offset and uintptr (size_t) for the length by reason of compatibility.
 	if offset < 0 {
 		return nil, &ErrorInvalidOffset{Offset: offset}
 	}
+	length, err := mappedLength(f, offset, o.length)
+	if err != nil {
+		return nil, err
+	}
 	if length > uintptr(maxInt) {
 		return nil, &ErrorInvalidLength{Length: length}
 	}
 
 	m := &Mapping{}
+	m.mode = mode
+	m.safe = flags&FlagSafeAccess != 0
 	prot := syscall.PROT_READ
 	mmapFlags := syscall.MAP_SHARED
-	if mode < ModeReadOnly || mode > ModeWriteCopy {
-		return nil, &ErrorInvalidMode{Mode: mode}
-	}
 	if mode > ModeReadOnly {
 		prot |= syscall.PROT_WRITE
 		m.writable = true
 	}
 	if mode == ModeWriteCopy {
-		flags = syscall.MAP_PRIVATE
+		mmapFlags = syscall.MAP_PRIVATE
 	}
 	if flags&FlagExecutable != 0 {
 		prot |= syscall.PROT_EXEC
 		m.executable = true
 	}
+	if flags&FlagPopulate != 0 {
+		mmapFlags |= syscall.MAP_POPULATE
+	}
+	if flags&FlagNoReserve != 0 {
+		mmapFlags |= syscall.MAP_NORESERVE
+	}
+	if o.placed {
+		if flags&FlagGuardPages != 0 {
+			return nil, &ErrorInvalidFlags{Flags: flags}
+		}
+		mmapFlags |= syscall.MAP_FIXED
+	}
 
 	// Mapping offset must be aligned by the memory page size.
 	pageSize := int64(os.Getpagesize())
 	if pageSize < 0 {
 		return nil, os.NewSyscallError("getpagesize", syscall.EINVAL)
 	}
-	outerOffset := offset / pageSize
+	if flags&FlagGuardPages != 0 {
+		m.guard = uintptr(pageSize)
+	}
+
+	// Files on the hugetlbfs are always mapped using huge pages.
+	hugePageSize, err := fileHugePageSize(f)
+	if err != nil {
+		return nil, err
+	}
+	if flags&FlagHugePages != 0 {
+		size, err := flagHugePageSize(flags)
+		if err != nil {
+			return nil, err
+		}
+		if size != hugePageSize {
+			return nil, &ErrorHugePages{PageSize: size, Err: syscall.EINVAL}
+		}
+	}
+	if hugePageSize != 0 {
+		if m.guard != 0 {
+			return nil, &ErrorInvalidFlags{Flags: flags}
+		}
+		pageSize = int64(hugePageSize)
+		m.hugetlb = true
+	}
+	m.pageSize = uintptr(pageSize)
+
 	innerOffset := offset % pageSize
-	m.alignedLength = uintptr(innerOffset) + length
+	m.offset = offset - innerOffset
+	m.alignedLength = m.roundLength(uintptr(innerOffset) + length)
 
-	var err error
-	m.alignedAddress, err = mmap(0, m.alignedLength, prot, mmapFlags, fd, outerOffset)
+	m.prot = prot
+	m.flags = mmapFlags
+	m.alignedAddress, err = m.mapRegion(o.hint, m.alignedLength, prot, f.Fd(), m.offset)
 	if err != nil {
-		return nil, os.NewSyscallError("mmap", err)
+		return nil, err
 	}
+	m.flags &^= syscall.MAP_FIXED
+	m.file = f
 	m.address = m.alignedAddress + uintptr(innerOffset)
 
 	// Convert the mapping into a byte slice.
-	var sliceHeader struct {
-		data uintptr
-		len  int
-		cap  int
+	m.memory = byteSlice(m.address, length)
+	if flags&FlagLock != 0 {
+		if err := m.Lock(); err != nil {
+			m.Close()
+			return nil, err
+		}
 	}
-	sliceHeader.data = m.address
-	sliceHeader.len = int(length)
-	sliceHeader.cap = sliceHeader.len
-	m.memory = *(*[]byte)(unsafe.Pointer(&sliceHeader))
 
 	runtime.SetFinalizer(m, (*Mapping).Close)
 	return m, nil
 }
 
+// mapRegion maps the memory region of given aligned length surrounded by the guard pages if needed.
+// Non-zero hint specifies the preferred address of the memory region.
+func (m *Mapping) mapRegion(hint, alignedLength uintptr, prot int, fd uintptr, offset int64) (uintptr, error) {
+	if m.guard == 0 {
+		address, err := mmap(hint, alignedLength, prot, m.flags, fd, offset)
+		if err != nil {
+			return 0, os.NewSyscallError("mmap", err)
+		}
+		return address, nil
+	}
+	if hint != 0 {
+		hint -= m.guard
+	}
+	guardedLength := m.guardedLength(alignedLength)
+	reserved, err := mmap(
+		hint, guardedLength, syscall.PROT_NONE,
+		syscall.MAP_PRIVATE|syscall.MAP_ANONYMOUS|syscall.MAP_NORESERVE, ^uintptr(0), 0,
+	)
+	if err != nil {
+		return 0, os.NewSyscallError("mmap", err)
+	}
+	address, err := mmap(reserved+m.guard, alignedLength, prot, m.flags|syscall.MAP_FIXED, fd, offset)
+	if err != nil {
+		munmap(reserved, guardedLength)
+		return 0, os.NewSyscallError("mmap", err)
+	}
+	return address, nil
+}
+
+// unmapRegion unmaps the memory region of given address and aligned length with its guard pages.
+func (m *Mapping) unmapRegion(address, alignedLength uintptr) error {
+	if err := munmap(address-m.guard, m.guardedLength(alignedLength)); err != nil {
+		return os.NewSyscallError("munmap", err)
+	}
+	return nil
+}
+
+// roundLength rounds given aligned length up to the huge page size if needed.
+// Unlike the ordinary memory pages, the huge pages must be mapped and unmapped entirely.
+func (m *Mapping) roundLength(alignedLength uintptr) uintptr {
+	if !m.hugetlb {
+		return alignedLength
+	}
+	return (alignedLength + m.pageSize - 1) &^ (m.pageSize - 1)
+}
+
+// guardedLength returns the length of the memory region of given aligned length with its guard pages.
+func (m *Mapping) guardedLength(alignedLength uintptr) uintptr {
+	if m.guard == 0 {
+		return alignedLength
+	}
+	return (alignedLength+m.guard-1)&^(m.guard-1) + 2*m.guard
+}
+
+// NewAnonymous returns a new anonymous mapping which is not backed by any file.
+// Mapping in the ModeReadWrite mode is shared with the child processes,
+// mapping in the ModeWriteCopy mode is private.
+// Mapped memory is initialized to zero.
+func NewAnonymous(length uintptr, mode Mode, flags Flag) (*Mapping, error) {
+	if length == 0 || length > uintptr(maxInt) {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	o := &options{mode: mode, flags: flags}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	m := &Mapping{}
+	m.mode = mode
+	m.safe = flags&FlagSafeAccess != 0
+	prot := syscall.PROT_READ
+	mmapFlags := syscall.MAP_SHARED | syscall.MAP_ANONYMOUS
+	if mode > ModeReadOnly {
+		prot |= syscall.PROT_WRITE
+		m.writable = true
+	}
+	if mode == ModeWriteCopy {
+		mmapFlags = syscall.MAP_PRIVATE | syscall.MAP_ANONYMOUS
+	}
+	if flags&FlagExecutable != 0 {
+		prot |= syscall.PROT_EXEC
+		m.executable = true
+	}
+	if flags&FlagPopulate != 0 {
+		mmapFlags |= syscall.MAP_POPULATE
+	}
+	if flags&FlagNoReserve != 0 {
+		mmapFlags |= syscall.MAP_NORESERVE
+	}
+
+	m.pageSize = uintptr(os.Getpagesize())
+	if flags&FlagGuardPages != 0 {
+		m.guard = m.pageSize
+	}
+	if flags&FlagHugePages != 0 {
+		size, err := flagHugePageSize(flags)
+		if err != nil {
+			return nil, err
+		}
+		m.pageSize = size
+		m.hugetlb = true
+		mmapFlags |= syscall.MAP_HUGETLB | hugePageShift(size)
+	}
+
+	var err error
+	m.prot = prot
+	m.flags = mmapFlags
+	m.alignedLength = m.roundLength(length)
+	m.alignedAddress, err = m.mapRegion(0, m.alignedLength, prot, ^uintptr(0), 0)
+	if err != nil {
+		if m.hugetlb {
+			return nil, &ErrorHugePages{PageSize: m.pageSize, Err: err.(*os.SyscallError).Err}
+		}
+		return nil, err
+	}
+	m.address = m.alignedAddress
+	m.memory = byteSlice(m.address, length)
+	if flags&FlagLock != 0 {
+		if err := m.Lock(); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+
+	runtime.SetFinalizer(m, (*Mapping).Close)
+	return m, nil
+}
+
+// Resize changes the mapped memory length.
+// The memory pages are remapped in place whenever possible, otherwise they are moved to another address.
+// In the last case true is returned and all slices previously returned by Memory become invalid.
+// The underlying file is extended when the mapping in the ModeReadWrite mode grows beyond its end,
+// and it is truncated when the mapping which reached the end of the file shrinks.
+// Mappings in other modes can not grow beyond the end of the file.
+// Lock state of the mapped memory pages is kept.
+// Mappings which are sealed, placed within the reservation or split by AdviseRange can not be resized,
+// see AdviseRange for details.
+func (m *Mapping) Resize(length uintptr) (bool, error) {
+	if m.memory == nil {
+		return false, &ErrorClosed{}
+	}
+	if length == 0 || length > uintptr(maxInt) {
+		return false, &ErrorInvalidLength{Length: length}
+	}
+	if m.reservation != nil || m.sealed || m.split != 0 {
+		return false, &ErrorIllegalOperation{Operation: "resize"}
+	}
+	innerOffset := m.address - m.alignedAddress
+	alignedLength := m.roundLength(innerOffset + length)
+
+	fileLength := int64(-1)
+	highOffset := m.offset + int64(alignedLength)
+	if m.file != nil {
+		info, err := m.file.Stat()
+		if err != nil {
+			return false, err
+		}
+		if info.Mode().IsRegular() {
+			fileLength = info.Size()
+		}
+	}
+
+	// The file is resized only by the mapping in the ModeReadWrite mode,
+	// other mappings can not grow beyond its end.
+	if fileLength >= 0 && m.mode != ModeReadWrite {
+		offset := m.offset + int64(innerOffset)
+		if length > uintptr(len(m.memory)) && offset+int64(length) > fileLength {
+			return false, &ErrorBeyondEOF{Offset: offset, Length: length, Size: fileLength}
+		}
+		fileLength = -1
+	}
+
+	// Memory pages may be remapped only if they have the same attributes,
+	// which are restored whether the mapping is remapped or not.
+	if err := m.unprotect(); err != nil {
+		m.reattribute()
+		return false, err
+	}
+	if err := m.unlockRanges(); err != nil {
+		m.reattribute()
+		return false, err
+	}
+
+	extended := fileLength >= 0 && highOffset > fileLength
+	if extended {
+		if err := m.file.Truncate(highOffset); err != nil {
+			m.reattribute()
+			return false, err
+		}
+	}
+
+	var address uintptr
+	var err error
+	if m.file == nil && m.flags&syscall.MAP_SHARED != 0 && alignedLength > m.alignedLength {
+		address, err = m.moveAnonymous(alignedLength)
+	} else if m.guard != 0 {
+		address, err = m.moveGuarded(alignedLength)
+	} else {
+		address, err = mremap(m.alignedAddress, m.alignedLength, alignedLength, mremapMayMove, 0)
+		if err != nil {
+			err = os.NewSyscallError("mremap", err)
+		}
+	}
+	if err != nil {
+		if extended {
+			m.file.Truncate(fileLength)
+		}
+		m.reattribute()
+		return false, err
+	}
+	moved := address != m.alignedAddress
+	truncated := fileLength >= 0 && highOffset < fileLength && m.offset+int64(m.alignedLength) >= fileLength
+	m.alignedAddress = address
+	m.alignedLength = alignedLength
+	m.address = address + innerOffset
+	m.memory = byteSlice(m.address, length)
+
+	if err := m.reattribute(); err != nil {
+		return moved, err
+	}
+	if truncated {
+		if err := m.file.Truncate(highOffset); err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// reattribute locks and protects the mapped memory pages again after the attempt to remap them.
+// Newly mapped memory pages are locked too if the whole mapping is locked.
+// All the attributes are restored even if some of them fail to be, the first error is returned.
+func (m *Mapping) reattribute() error {
+	var err error
+	if m.locked {
+		if lockErr := mlock(m.alignedAddress, m.alignedLength); lockErr != nil {
+			err = os.NewSyscallError("mlock", lockErr)
+		}
+	}
+	if lockErr := m.relockRanges(); lockErr != nil && err == nil {
+		err = lockErr
+	}
+	if protectErr := m.reprotect(); protectErr != nil && err == nil {
+		err = protectErr
+	}
+	return err
+}
+
+// moveAnonymous moves the shared anonymous mapping to the new memory region of given length.
+// Unlike the private one, such mapping can not be extended in place
+// because the size of its underlying shared memory object is fixed.
+func (m *Mapping) moveAnonymous(alignedLength uintptr) (uintptr, error) {
+	address, err := m.mapRegion(0, alignedLength, m.prot|syscall.PROT_WRITE, ^uintptr(0), 0)
+	if err != nil {
+		return 0, err
+	}
+	copy(byteSlice(address, m.alignedLength), byteSlice(m.alignedAddress, m.alignedLength))
+	if m.prot&syscall.PROT_WRITE == 0 {
+		if err := mprotect(address, alignedLength, m.prot); err != nil {
+			m.unmapRegion(address, alignedLength)
+			return 0, os.NewSyscallError("mprotect", err)
+		}
+	}
+	if err := m.unmapRegion(m.alignedAddress, m.alignedLength); err != nil {
+		m.unmapRegion(address, alignedLength)
+		return 0, err
+	}
+	return address, nil
+}
+
+// moveGuarded moves the mapping to the new memory region of given length surrounded by the guard pages.
+// Such mapping can not be extended in place because it is followed by the guard page.
+func (m *Mapping) moveGuarded(alignedLength uintptr) (uintptr, error) {
+	guardedLength := m.guardedLength(alignedLength)
+	reserved, err := mmap(
+		0, guardedLength, syscall.PROT_NONE,
+		syscall.MAP_PRIVATE|syscall.MAP_ANONYMOUS|syscall.MAP_NORESERVE, ^uintptr(0), 0,
+	)
+	if err != nil {
+		return 0, os.NewSyscallError("mmap", err)
+	}
+	address, err := mremap(m.alignedAddress, m.alignedLength, alignedLength, mremapMayMove|mremapFixed, reserved+m.guard)
+	if err != nil {
+		munmap(reserved, guardedLength)
+		return 0, os.NewSyscallError("mremap", err)
+	}
+
+	// Only the guard pages of the old memory region are left.
+	if err := m.unmapRegion(m.alignedAddress, m.alignedLength); err != nil {
+		return address, err
+	}
+	return address, nil
+}
+
 // Lock locks the mapped memory pages.
 // All pages that contain a part of mapping address range
 // are guaranteed to be resident in RAM when the call returns successfully.
 // The pages are guaranteed to stay in RAM until later unlocked.
 // It may need to increase process memory limits for operation success.
 // See working set on Windows and rlimit on Linux for details.
+// It fails if any range of the mapped memory pages was locked by LockRange.
 func (m *Mapping) Lock() error {
 	if m.memory == nil {
 		return &ErrorClosed{}
 	}
-	if m.locked {
+	if m.locked || len(m.lockedRanges) > 0 {
 		return &ErrorLocked{}
 	}
 	if err := mlock(m.alignedAddress, m.alignedLength); err != nil {
@@ -167,6 +583,7 @@ func (m *Mapping) Unlock() error {
 }
 
 // Sync synchronizes this mapping with the underlying file.
+// It does nothing for the anonymous mapping.
 func (m *Mapping) Sync() error {
 	if m.memory == nil {
 		return &ErrorClosed{}
@@ -174,16 +591,61 @@ func (m *Mapping) Sync() error {
 	if !m.writable {
 		return &ErrorIllegalOperation{Operation: "sync"}
 	}
-	return os.NewSyscallError("msync", msync(m.alignedAddress, m.alignedLength))
+	if m.file == nil {
+		return nil
+	}
+	return m.sync(m.alignedAddress, m.alignedLength, 0)
+}
+
+// SyncRange synchronizes the mapped memory starting from given offset and ends after given length
+// with the underlying file using given flags.
+// Actual range may be different than the specified by the reason of aligning to page size.
+// It does nothing for the anonymous mapping.
+func (m *Mapping) SyncRange(offset int64, length uintptr, flags SyncFlag) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	if !m.writable {
+		return &ErrorIllegalOperation{Operation: "sync"}
+	}
+	address, alignedLength, err := m.alignRange(offset, length)
+	if err != nil {
+		return err
+	}
+	if m.file == nil {
+		return nil
+	}
+	return m.sync(address, alignedLength, flags)
+}
+
+func (m *Mapping) sync(address, length uintptr, flags SyncFlag) error {
+	msyncFlags := syscall.MS_SYNC
+	if flags&SyncFlagAsync != 0 {
+		msyncFlags = syscall.MS_ASYNC
+	}
+	if flags&SyncFlagInvalidate != 0 {
+		msyncFlags |= syscall.MS_INVALIDATE
+	}
+	return os.NewSyscallError("msync", msync(address, length, msyncFlags))
 }
 
 // Close closes this mapping and frees all resources associated with it.
 // Mapping will be synchronized with the underlying file and unlocked automatically.
+// Sealed mapping can not be closed.
+// Memory of the secure mapping is overwritten with zeros.
 // Implementation of io.Closer.
 func (m *Mapping) Close() error {
 	if m.memory == nil {
 		return &ErrorClosed{}
 	}
+	if m.sealed {
+		return &ErrorIllegalOperation{Operation: "close"}
+	}
+	if m.secure {
+		if err := m.wipe(); err != nil {
+			return err
+		}
+	}
 
 	// Maybe unnecessary.
 	if m.writable {
@@ -196,9 +658,21 @@ func (m *Mapping) Close() error {
 			return err
 		}
 	}
+	if err := m.unlockRanges(); err != nil {
+		return err
+	}
 
-	if err := munmap(m.alignedAddress, m.alignedLength); err != nil {
-		return os.NewSyscallError("munmap", err)
+	if m.reservation != nil {
+		if err := m.unplace(); err != nil {
+			return err
+		}
+	} else if err := m.unmapRegion(m.alignedAddress, m.alignedLength); err != nil {
+		return err
+	}
+	if m.file != nil {
+		if err := m.file.Close(); err != nil {
+			return err
+		}
 	}
 	*m = Mapping{}
 	runtime.SetFinalizer(m, nil)
@@ -0,0 +1,700 @@
+package mmap
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestLockLimit(t *testing.T) {
+	limit, err := GetLockLimit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit.Current > limit.Max {
+		t.Fatalf("soft limit %d must not exceed hard limit %d", limit.Current, limit.Max)
+	}
+	raised, err := RaiseLockLimit(limit.Max)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raised.Current != limit.Max {
+		t.Fatalf("soft limit must be %d, %d found", limit.Max, raised.Current)
+	}
+}
+
+func TestAdviseRangeResize(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	pageSize := uintptr(os.Getpagesize())
+	if err := m.Advise(AdviceDontFork); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AdviseRange(0, pageSize, AdviceWillNeed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Grow(testLength); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AdviseRange(0, pageSize, AdviceDontDump); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AdviseRange(0, pageSize, AdviceRandom); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Grow(testLength); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if err := m.Advise(AdviceDontDump); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Grow(testLength); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if err := m.Advise(AdviceNormal); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Grow(testLength); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func isGuarded(t *testing.T, address, length uintptr) bool {
+	data, err := ioutil.ReadFile("/proc/self/maps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageSize := uintptr(os.Getpagesize())
+	low := fmt.Sprintf("-%x", address)
+	high := fmt.Sprintf("%x-", (address+length+pageSize-1)&^(pageSize-1))
+	before, after := false, false
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[1] != "---p" {
+			continue
+		}
+		if strings.HasSuffix(fields[0], low) {
+			before = true
+		}
+		if strings.HasPrefix(fields[0], high) {
+			after = true
+		}
+	}
+	return before && after
+}
+
+func TestGuardPages(t *testing.T) {
+	m, err := NewAnonymous(testLength, ModeWriteCopy, FlagGuardPages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if !isGuarded(t, m.Address(), m.Length()) {
+		t.Fatal("mapping must be surrounded by guard pages")
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Grow(testLength); err != nil {
+		t.Fatal(err)
+	}
+	if !isGuarded(t, m.Address(), m.Length()) {
+		t.Fatal("resized mapping must be surrounded by guard pages")
+	}
+	buf := make([]byte, len(testBuffer))
+	if _, err := m.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+}
+
+func TestPopulate(t *testing.T) {
+	f, err := makeTestFile(t, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	m, err := New(f.Fd(), 0, testLength, ModeReadOnly, FlagPopulate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if ratio, err := m.ResidentRatio(0, m.Length()); err != nil {
+		t.Fatal(err)
+	} else if ratio != 1 {
+		t.Fatalf("resident ratio must be 1, %f found", ratio)
+	}
+}
+
+func TestSafeAccess(t *testing.T) {
+	pageSize := int64(os.Getpagesize())
+	f, err := makeTestFile(t, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	m, err := New(f.Fd(), 0, uintptr(2*pageSize), ModeReadWrite, FlagSafeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	tx, err := m.Begin(pageSize, uintptr(len(testBuffer)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(pageSize); err != nil {
+		t.Fatal(err)
+	}
+	p, err := m.Prefetch(context.Background(), 0, m.Length())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(testBuffer))
+	if _, err := m.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ReadAt(buf, pageSize); err == nil {
+		t.Fatal("expected ErrorFault, no error found")
+	} else if fault, ok := err.(*ErrorFault); !ok {
+		t.Fatalf("expected ErrorFault, [%v] error found", err)
+	} else if fault.Offset != pageSize {
+		t.Fatalf("fault offset must be %d, %d found", pageSize, fault.Offset)
+	}
+	if _, ok := tx.Commit().(*ErrorFault); !ok {
+		t.Fatal("commit must fail with ErrorFault")
+	}
+	if _, err := m.Begin(pageSize, uintptr(len(testBuffer))); err == nil {
+		t.Fatal("expected ErrorFault, no error found")
+	}
+	err = m.Guard(func() {
+		m.Memory()[pageSize+1] = 1
+	})
+	if fault, ok := err.(*ErrorFault); !ok || fault.Offset != pageSize+1 {
+		t.Fatalf("expected ErrorFault at offset %d, [%v] error found", pageSize+1, err)
+	}
+}
+
+func TestMemfd(t *testing.T) {
+	m, err := NewMemfd("mmap.test", int64(testLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddFileSeals(FileSealShrink | FileSealGrow); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Writable() {
+		t.Fatal("mapping must be writable")
+	}
+	if err := m.AddFileSeals(FileSealWrite | FileSealSeal); err != nil {
+		t.Fatal(err)
+	}
+	if seals, err := m.FileSeals(); err != nil {
+		t.Fatal(err)
+	} else if seals != FileSealShrink|FileSealGrow|FileSealWrite|FileSealSeal {
+		t.Fatalf("seals must be 0x%x, 0x%x found", FileSealShrink|FileSealGrow|FileSealWrite|FileSealSeal, seals)
+	}
+	if m.Writable() {
+		t.Fatal("mapping must not be writable")
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if _, err := m.File().WriteAt(testBuffer, 0); err == nil {
+		t.Fatal("sealed file must not be written")
+	}
+	if err := m.File().Truncate(int64(testLength) / 2); err == nil {
+		t.Fatal("sealed file must not be shrunk")
+	}
+	f, err := os.Open(fmt.Sprintf("/proc/self/fd/%d", m.File().Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	r, err := New(f.Fd(), 0, testLength, ModeReadOnly, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, r)
+	buf := make([]byte, len(testBuffer))
+	if _, err := m.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+}
+
+func TestShared(t *testing.T) {
+	name := fmt.Sprintf("mmap.test.%d", os.Getpid())
+	m, err := OpenShared(name, int64(testLength), ModeReadWrite, 0600, WithExclusive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer UnlinkShared(name)
+	defer testClose(t, m)
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenShared(name, int64(testLength), ModeReadWrite, 0600, WithExclusive()); !os.IsExist(err) {
+		t.Fatalf("expected existence error, [%v] error found", err)
+	}
+	r, err := OpenShared("/"+name, 0, ModeReadOnly, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, r)
+	buf := make([]byte, len(testBuffer))
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+	list, err := ListShared()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, info := range list {
+		if info.Name == name {
+			found = true
+			if info.Size != int64(testLength) || info.UID != os.Getuid() {
+				t.Fatalf("shared memory object must be of %d bytes owned by %d, %+v found", testLength, os.Getuid(), info)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("shared memory object %q must be listed", name)
+	}
+	if err := UnlinkShared(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenShared(name, 0, ModeReadOnly, 0); !os.IsNotExist(err) {
+		t.Fatalf("expected non-existence error, [%v] error found", err)
+	}
+	if _, err := OpenShared("a/b", 0, ModeReadOnly, 0); err == nil {
+		t.Fatal("expected ErrorInvalidName, no error found")
+	} else if _, ok := err.(*ErrorInvalidName); !ok {
+		t.Fatalf("expected ErrorInvalidName, [%v] error found", err)
+	}
+	if _, err := OpenShared(name, int64(testLength), Mode(-1), 0600); err == nil {
+		t.Fatal("expected ErrorInvalidMode, no error found")
+	} else if _, ok := err.(*ErrorInvalidMode); !ok {
+		t.Fatalf("expected ErrorInvalidMode, [%v] error found", err)
+	}
+	if _, err := OpenShared(name, int64(testLength), ModeReadWrite, 0600, WithRange(0, 2*testLength)); err == nil {
+		t.Fatal("expected ErrorBeyondEOF, no error found")
+	} else if _, ok := err.(*ErrorBeyondEOF); !ok {
+		t.Fatalf("expected ErrorBeyondEOF, [%v] error found", err)
+	}
+	if _, err := OpenShared(name, 0, ModeReadOnly, 0); !os.IsNotExist(err) {
+		t.Fatalf("expected non-existence error, [%v] error found", err)
+	}
+}
+
+func makeTestConn(t *testing.T, fd int) *net.UnixConn {
+	f := os.NewFile(uintptr(fd), "")
+	defer testClose(t, f)
+	conn, err := net.FileConn(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn.(*net.UnixConn)
+}
+
+func TestTransfer(t *testing.T) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, receiver := makeTestConn(t, fds[0]), makeTestConn(t, fds[1])
+	defer testClose(t, sender)
+	defer testClose(t, receiver)
+	f, err := makeTestFile(t, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	offset := int64(os.Getpagesize() + 1)
+	m, err := New(f.Fd(), offset, uintptr(len(testBuffer)), ModeReadWrite, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	meta := []byte("meta")
+	if err := SendMapping(sender, m, meta); err != nil {
+		t.Fatal(err)
+	}
+	r, rMeta, err := ReceiveMapping(receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, r)
+	if bytes.Compare(rMeta, meta) != 0 {
+		t.Fatalf("metadata must be a %q, %q found", meta, rMeta)
+	}
+	if r.Length() != m.Length() || !r.Writable() {
+		t.Fatalf("received mapping must be writable of %d bytes, %d bytes found", m.Length(), r.Length())
+	}
+	if _, err := r.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(testBuffer))
+	if _, err := m.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+	if err := SendMapping(sender, m, make([]byte, MaxTransferMetaLength+1)); err == nil {
+		t.Fatal("expected ErrorInvalidLength, no error found")
+	} else if _, ok := err.(*ErrorInvalidLength); !ok {
+		t.Fatalf("expected ErrorInvalidLength, [%v] error found", err)
+	}
+	var header bytes.Buffer
+	if err := binary.Write(&header, binary.LittleEndian, &transferHeader{Length: 1, MetaLength: ^uint32(0)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := sender.WriteMsgUnix(header.Bytes(), syscall.UnixRights(int(f.Fd())), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReceiveMapping(receiver); err == nil {
+		t.Fatal("expected ErrorInvalidLength, no error found")
+	} else if _, ok := err.(*ErrorInvalidLength); !ok {
+		t.Fatalf("expected ErrorInvalidLength, [%v] error found", err)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	pageSize := os.Getpagesize()
+	f, err := makeTestFile(t, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	if _, err := f.WriteAt(bytes.Repeat([]byte{1}, int(testLength)), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(f.Fd(), 0, testLength, ModeReadWrite, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	low, high := pageSize/2, pageSize/2+3*pageSize
+	if err := m.Discard(int64(low), uintptr(high-low)); err != nil {
+		t.Fatal(err)
+	}
+	memory := m.Memory()
+	for i := range memory {
+		if expected := i < low || i >= high; (memory[i] == 1) != expected {
+			t.Fatalf("byte at offset %d must be non-zero: %v, %d found", i, expected, memory[i])
+		}
+	}
+	holes := m.Holes()
+	for holes.Next() {
+		if holes.Offset() < int64(low) || holes.Offset()+int64(holes.Length()) > int64(high) {
+			t.Fatalf("hole of %d bytes at offset %d must be in the discarded range", holes.Length(), holes.Offset())
+		}
+	}
+	if err := holes.Err(); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewAnonymous(testLength, ModeWriteCopy, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, a)
+	if _, err := a.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Discard(0, testLength); err != nil {
+		t.Fatal(err)
+	}
+	if ratio, err := a.ResidentRatio(0, testLength); err != nil {
+		t.Fatal(err)
+	} else if ratio != 0 {
+		t.Fatalf("resident ratio must be 0, %f found", ratio)
+	}
+}
+
+func isReserved(t *testing.T, address uintptr) bool {
+	data, err := ioutil.ReadFile("/proc/self/maps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		var low, high uintptr
+		var perms string
+		if _, err := fmt.Sscanf(line, "%x-%x %s", &low, &high, &perms); err != nil {
+			continue
+		}
+		if address >= low && address < high {
+			return perms == "---p"
+		}
+	}
+	return false
+}
+
+func TestReserve(t *testing.T) {
+	pageSize := uintptr(os.Getpagesize())
+	f, err := makeTestFile(t, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	r, err := Reserve(8 * pageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	m, err := r.MapAt(2*pageSize, f.Fd(), 0, 2*pageSize, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Address() != r.Address()+2*pageSize {
+		t.Fatalf("mapping address must be 0x%x, 0x%x found", r.Address()+2*pageSize, m.Address())
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.MapAt(3*pageSize, f.Fd(), 0, pageSize, ModeReadOnly); err == nil {
+		t.Fatal("expected ErrorOverlap, no error found")
+	} else if _, ok := err.(*ErrorOverlap); !ok {
+		t.Fatalf("expected ErrorOverlap, [%v] error found", err)
+	}
+	ro, err := os.Open(testPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, ro)
+	if _, err := r.MapAt(5*pageSize, ro.Fd(), 0, pageSize, ModeReadWrite); err == nil {
+		t.Fatal("expected error, no error found")
+	}
+	if !isReserved(t, r.Address()+5*pageSize) {
+		t.Fatal("memory must stay reserved")
+	}
+	if _, err := m.Resize(4 * pageSize); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	m, err = r.MapAt(3*pageSize, f.Fd(), 0, pageSize, ModeReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(testBuffer))
+	if _, err := m.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+	if err := r.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ReadAt(buf, 0); err == nil {
+		t.Fatal("expected ErrorClosed, no error found")
+	}
+}
+
+func TestSeal(t *testing.T) {
+	m, err := NewAnonymous(uintptr(os.Getpagesize()), ModeWriteCopy, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Seal(); err != nil {
+		testClose(t, m)
+		if _, ok := err.(*ErrorUnsupported); ok {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := munmap(m.alignedAddress, m.alignedLength); err != syscall.EPERM {
+		t.Fatalf("expected EPERM, [%v] error found", err)
+	}
+	if err := m.Protect(0, m.Length(), ProtectionRead); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if _, err := m.Grow(testLength); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	s := NewSafeMapping(m)
+	if err := s.Close(); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if _, err := s.ReadAt(make([]byte, len(testBuffer)), 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func vmFlags(t *testing.T, address uintptr) []string {
+	data, err := ioutil.ReadFile("/proc/self/smaps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := fmt.Sprintf("%x-", address)
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			found = true
+		} else if found && strings.HasPrefix(line, "VmFlags:") {
+			return strings.Fields(line)[1:]
+		}
+	}
+	return nil
+}
+
+func TestSecure(t *testing.T) {
+	m, err := NewSecure(uintptr(len(testBuffer)))
+	if err != nil {
+		if _, ok := err.(*ErrorUnsupported); ok {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if !isGuarded(t, m.Address(), m.Length()) {
+		t.Fatal("secure mapping must be surrounded by guard pages")
+	}
+	flags := strings.Join(vmFlags(t, m.Address()), " ")
+	for _, flag := range []string{"lo", "dd", "wf"} {
+		if !strings.Contains(flags, flag) {
+			t.Fatalf("memory flags must contain %q, %q found", flag, flags)
+		}
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Freeze(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if _, err := m.Resize(1 << 40); err == nil {
+		t.Fatal("expected error, no error found")
+	}
+	flags = strings.Join(vmFlags(t, m.Address()), " ")
+	if strings.Contains(flags, "wr") || !strings.Contains(flags, "lo") {
+		t.Fatalf("memory flags must contain %q but not %q, %q found", "lo", "wr", flags)
+	}
+	if err := m.Thaw(); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Writable() {
+		t.Fatal("thawed mapping must be writable")
+	}
+	if err := m.Freeze(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.wipe(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(m.Memory(), emptyBuffer) != 0 {
+		t.Fatalf("memory must be a %q, %v found", emptyBuffer, m.Memory())
+	}
+	if err := m.Freeze(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHugePages(t *testing.T) {
+	sizes, err := HugePageSizes()
+	if err != nil {
+		if _, ok := err.(*ErrorUnsupported); ok {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+	size, err := DefaultHugePageSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, s := range sizes {
+		found = found || s == size
+	}
+	if !found {
+		t.Fatalf("default huge page size %d must be one of %v", size, sizes)
+	}
+	if _, err := NewAnonymous(size, ModeWriteCopy, FlagHugePages|FlagGuardPages); err == nil {
+		t.Fatal("expected ErrorInvalidFlags, no error found")
+	} else if _, ok := err.(*ErrorInvalidFlags); !ok {
+		t.Fatalf("expected ErrorInvalidFlags, [%v] error found", err)
+	}
+	m, err := NewAnonymous(1, ModeWriteCopy, FlagHugePages)
+	if err != nil {
+		if _, ok := err.(*ErrorHugePages); !ok {
+			t.Fatalf("expected ErrorHugePages, [%v] error found", err)
+		}
+	} else {
+		defer testClose(t, m)
+		if m.PageSize() != size {
+			t.Fatalf("page size must be %d, %d found", size, m.PageSize())
+		}
+		if _, err := m.WriteAt(testBuffer, 0); err == nil {
+			t.Fatal("expected io.EOF, no error found")
+		}
+	}
+	f, err := makeTestFile(t, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	if _, err := New(f.Fd(), 0, testLength, ModeReadWrite, FlagHugePages); err == nil {
+		t.Fatal("expected ErrorHugePages, no error found")
+	} else if _, ok := err.(*ErrorHugePages); !ok {
+		t.Fatalf("expected ErrorHugePages, [%v] error found", err)
+	}
+}
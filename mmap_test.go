@@ -1,10 +1,13 @@
 package mmap
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -205,6 +208,133 @@ func TestOffset(t *testing.T) {
 	}
 }
 
+func TestOffsetModes(t *testing.T) {
+	pageSize := int64(os.Getpagesize())
+	offsets := []int64{0, 1, pageSize - 1, pageSize, pageSize + 1, 3*pageSize + 7, 1<<16 + 3}
+	modes := []Mode{ModeReadOnly, ModeReadWrite, ModeWriteCopy}
+	pattern := make([]byte, testLength)
+	for i := range pattern {
+		pattern[i] = byte(i % 251)
+	}
+	length := uintptr(2 * pageSize)
+	for _, mode := range modes {
+		for _, offset := range offsets {
+			f, err := makeTestFile(t, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.WriteAt(pattern, 0); err != nil {
+				testClose(t, f)
+				t.Fatal(err)
+			}
+			m, err := New(f.Fd(), offset, length, mode, 0)
+			if err != nil {
+				testClose(t, f)
+				t.Fatalf("mode %d, offset %d: %v", mode, offset, err)
+			}
+			if bytes.Compare(m.Memory(), pattern[offset:offset+int64(length)]) != 0 {
+				t.Errorf("mode %d, offset %d: mapped memory does not match the file", mode, offset)
+			}
+			_, err = m.WriteAt(testBuffer, 0)
+			if mode == ModeReadOnly {
+				if _, ok := err.(*ErrorIllegalOperation); !ok {
+					t.Errorf("mode %d, offset %d: expected illegal operation error, %v found", mode, offset, err)
+				}
+			} else if err != nil {
+				t.Errorf("mode %d, offset %d: %v", mode, offset, err)
+			}
+			testClose(t, m)
+			expected := pattern[offset : offset+int64(len(testBuffer))]
+			if mode == ModeReadWrite {
+				expected = testBuffer
+			}
+			buf := make([]byte, len(testBuffer))
+			if _, err := f.ReadAt(buf, offset); err != nil {
+				t.Error(err)
+			} else if bytes.Compare(buf, expected) != 0 {
+				t.Errorf("mode %d, offset %d: file must contain %v, %v found", mode, offset, expected, buf)
+			}
+			testClose(t, f)
+		}
+	}
+}
+
+func TestFileRange(t *testing.T) {
+	f, err := makeTestFile(t, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	m, err := New(f.Fd(), 1, 0, ModeReadOnly, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if m.Length() != testLength-1 {
+		t.Fatalf("mapping length must be %d, %d found", testLength-1, m.Length())
+	}
+	if _, err := New(f.Fd(), 1, testLength, ModeReadOnly, 0); err == nil {
+		t.Fatal("expected ErrorBeyondEOF, no error found")
+	} else if _, ok := err.(*ErrorBeyondEOF); !ok {
+		t.Fatalf("expected ErrorBeyondEOF, [%v] error found", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, r)
+	defer testClose(t, w)
+	if _, err := New(r.Fd(), 0, testLength, ModeReadOnly, 0); err == nil {
+		t.Fatal("expected ErrorUnmappable, no error found")
+	} else if _, ok := err.(*ErrorUnmappable); !ok {
+		t.Fatalf("expected ErrorUnmappable, [%v] error found", err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	f, err := makeTestFile(t, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	m, err := NewWithOptions(f.Fd(), 0, 0, WithMode(ModeReadWrite), WithPopulate(), WithLock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if m.Length() != testLength || !m.Writable() {
+		t.Fatalf("mapping must be writable of %d bytes, %d bytes found", testLength, m.Length())
+	}
+	if err := m.Lock(); err == nil {
+		t.Fatal("expected ErrorLocked, no error found")
+	} else if _, ok := err.(*ErrorLocked); !ok {
+		t.Fatalf("expected ErrorLocked, [%v] error found", err)
+	}
+	invalid := [][]Option{
+		{WithRange(0, testLength)},
+		{WithHugePages(12345)},
+	}
+	for _, opts := range invalid {
+		if _, err := NewWithOptions(f.Fd(), 0, 0, opts...); err == nil {
+			t.Fatal("expected ErrorInvalidOption, no error found")
+		} else if _, ok := err.(*ErrorInvalidOption); !ok {
+			t.Fatalf("expected ErrorInvalidOption, [%v] error found", err)
+		}
+	}
+	invalid = [][]Option{
+		{WithLock(), WithNoReserve()},
+		{WithGuardPages(), WithHugePages(0)},
+		{WithFlags(0x8000)},
+	}
+	for _, opts := range invalid {
+		if _, err := NewWithOptions(f.Fd(), 0, 0, opts...); err == nil {
+			t.Fatal("expected ErrorInvalidFlags, no error found")
+		} else if _, ok := err.(*ErrorInvalidFlags); !ok {
+			t.Fatalf("expected ErrorInvalidFlags, [%v] error found", err)
+		}
+	}
+}
+
 func TestTransactionRollback(t *testing.T) {
 	m, err := makeTestMapping(t, ModeReadWrite)
 	if err != nil {
@@ -269,3 +399,658 @@ func TestTransactionCommit(t *testing.T) {
 		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
 	}
 }
+
+func TestResize(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Grow(testLength); err != nil {
+		t.Fatal(err)
+	}
+	if m.Length() != 2*testLength {
+		t.Fatalf("length must be %d, %d found", 2*testLength, m.Length())
+	}
+	if _, err := m.WriteAt(testBuffer, int64(2*testLength)-int64(len(testBuffer))); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(testBuffer))
+	if _, err := m.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+	if _, err := m.Resize(testLength / 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.WriteAt(testBuffer, int64(testLength)); err == nil {
+		t.Fatal("expected ErrorInvalidOffset, no error found")
+	} else if _, ok := err.(*ErrorInvalidOffset); !ok {
+		t.Fatalf("expected ErrorInvalidOffset, [%v] error found", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(testPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(testLength/2) {
+		t.Fatalf("file size must be %d, %d found", testLength/2, info.Size())
+	}
+}
+
+func TestResizeBeyondEOF(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if _, err := m.Grow(testLength); err == nil {
+		t.Fatal("expected ErrorBeyondEOF, no error found")
+	} else if _, ok := err.(*ErrorBeyondEOF); !ok {
+		t.Fatalf("expected ErrorBeyondEOF, [%v] error found", err)
+	}
+	if m.Length() != testLength {
+		t.Fatalf("length must be %d, %d found", testLength, m.Length())
+	}
+	if _, err := m.Resize(testLength / 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(testPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(testLength) {
+		t.Fatalf("file size must be %d, %d found", testLength, info.Size())
+	}
+}
+
+func TestAnonymous(t *testing.T) {
+	for _, mode := range []Mode{ModeReadWrite, ModeWriteCopy} {
+		m, err := NewAnonymous(testLength, mode, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer testClose(t, m)
+		tx, err := m.Begin(0, m.Length())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.WriteAt(testBuffer, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.Grow(testLength); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, len(testBuffer))
+		if _, err := m.ReadAt(buf, 0); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Compare(buf, testBuffer) != 0 {
+			t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+		}
+		if _, err := m.ReadAt(buf, int64(2*testLength)-int64(len(buf))); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Compare(buf, emptyBuffer) != 0 {
+			t.Fatalf("buffer must be a %q, %v found", emptyBuffer, buf)
+		}
+		if err := m.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCreateOpen(t *testing.T) {
+	m, err := Create(testPath, int64(testLength), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if m.Name() != testPath {
+		t.Fatalf("name must be %q, %q found", testPath, m.Name())
+	}
+	if m.File() == nil {
+		t.Fatal("file must be kept open")
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	m, err = Open(testPath, ModeReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if m.Length() != testLength {
+		t.Fatalf("length must be %d, %d found", testLength, m.Length())
+	}
+	buf := make([]byte, len(testBuffer))
+	if _, err := m.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	m, err = Open(testPath, ModeReadOnly, WithRange(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if m.Length() != testLength-1 {
+		t.Fatalf("length must be %d, %d found", testLength-1, m.Length())
+	}
+	buf = make([]byte, len(testBuffer)-1)
+	if _, err := m.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer[1:]) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer[1:], buf)
+	}
+}
+
+func TestAdvise(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if err := m.Advise(AdviceSequential); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AdviseRange(1, m.Length()-1, AdviceWillNeed); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AdviseRange(1, m.Length(), AdviceNormal); err == nil {
+		t.Fatal("expected ErrorInvalidLength, no error found")
+	} else if _, ok := err.(*ErrorInvalidLength); !ok {
+		t.Fatalf("expected ErrorInvalidLength, [%v] error found", err)
+	}
+	if err := m.Advise(Advice(-1)); err == nil {
+		t.Fatal("expected ErrorInvalidAdvice, no error found")
+	} else if _, ok := err.(*ErrorInvalidAdvice); !ok {
+		t.Fatalf("expected ErrorInvalidAdvice, [%v] error found", err)
+	}
+}
+
+func TestSyncRange(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	offset := int64(testLength) / 2
+	tx, err := m.Begin(offset, uintptr(len(testBuffer)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.WriteAt(testBuffer, offset); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.CommitSync(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.WriteAt(testBuffer, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SyncRange(1, uintptr(len(testBuffer)), SyncFlagAsync|SyncFlagInvalidate); err != nil {
+		t.Fatal(err)
+	}
+	f, err := makeTestFile(t, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, f)
+	buf := make([]byte, len(testBuffer))
+	if _, err := f.ReadAt(buf, offset); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+}
+
+func TestLockRange(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	pageSize := uintptr(os.Getpagesize())
+	if err := m.LockRange(0, pageSize, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LockRange(int64(2*pageSize), pageSize, LockFlagOnFault); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LockRange(int64(pageSize)-1, 2, 0); err == nil {
+		t.Fatal("expected ErrorLocked, no error found")
+	} else if _, ok := err.(*ErrorLocked); !ok {
+		t.Fatalf("expected ErrorLocked, [%v] error found", err)
+	}
+	if err := m.Lock(); err == nil {
+		t.Fatal("expected ErrorLocked, no error found")
+	} else if _, ok := err.(*ErrorLocked); !ok {
+		t.Fatalf("expected ErrorLocked, [%v] error found", err)
+	}
+	if err := m.UnlockRange(0, 2*pageSize); err == nil {
+		t.Fatal("expected ErrorUnlocked, no error found")
+	} else if _, ok := err.(*ErrorUnlocked); !ok {
+		t.Fatalf("expected ErrorUnlocked, [%v] error found", err)
+	}
+	if err := m.UnlockRange(1, pageSize-1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Grow(testLength); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockRangeResize(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	pageSize := uintptr(os.Getpagesize())
+	if err := m.LockRange(0, pageSize, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Resize(8 * pageSize); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.UnlockRange(0, pageSize); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProtectPartialPage(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	pageSize := uintptr(os.Getpagesize())
+	offset := int64(pageSize) + 500
+	tx, err := m.Begin(offset, uintptr(len(testBuffer)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Protect(int64(pageSize)+100, 10, ProtectionRead); err != nil {
+		t.Fatal(err)
+	}
+	if prot, err := m.Protection(offset, 1); err != nil {
+		t.Fatal(err)
+	} else if prot != ProtectionRead {
+		t.Fatalf("protection must be 0x%x, 0x%x found", ProtectionRead, prot)
+	}
+	if _, err := m.WriteAt(testBuffer, offset); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if err := m.Fill(offset, 1, 0); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if _, err := m.WriteAt(testBuffer, int64(pageSize)-int64(len(testBuffer))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.WriteAt(testBuffer, int64(2*pageSize)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProtect(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	pageSize := uintptr(os.Getpagesize())
+	if err := m.Protect(int64(pageSize), pageSize, ProtectionRead); err != nil {
+		t.Fatal(err)
+	}
+	if m.Writable() {
+		t.Fatal("mapping must not be writable")
+	}
+	if _, err := m.WriteAt(testBuffer, int64(pageSize)); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Protect(0, pageSize, ProtectionExecute); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+	if _, err := m.Grow(testLength); err != nil {
+		t.Fatal(err)
+	}
+	if prot, err := m.Protection(int64(pageSize), 1); err != nil {
+		t.Fatal(err)
+	} else if prot != ProtectionRead {
+		t.Fatalf("protection must be 0x%x, 0x%x found", ProtectionRead, prot)
+	}
+	if err := m.Protect(0, m.Length(), ProtectionRead|ProtectionWrite); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Writable() {
+		t.Fatal("mapping must be writable")
+	}
+	if _, err := m.WriteAt(testBuffer, int64(pageSize)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResident(t *testing.T) {
+	pageSize := uintptr(os.Getpagesize())
+	m, err := NewAnonymous(4*pageSize, ModeWriteCopy, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	pages, err := m.Resident()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 4 {
+		t.Fatalf("number of pages must be 4, %d found", len(pages))
+	}
+	if !pages[0] || pages[3] {
+		t.Fatalf("only the first page must be resident, %v found", pages)
+	}
+	if ratio, err := m.ResidentRatio(1, pageSize-1); err != nil {
+		t.Fatal(err)
+	} else if ratio != 1 {
+		t.Fatalf("resident ratio must be 1, %f found", ratio)
+	}
+	if ratio, err := m.ResidentRatio(int64(3*pageSize), pageSize); err != nil {
+		t.Fatal(err)
+	} else if ratio != 0 {
+		t.Fatalf("resident ratio must be 0, %f found", ratio)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	p, err := m.Prefetch(context.Background(), 0, m.Length())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if done, total := p.Progress(); done != total || total != m.Length() {
+		t.Fatalf("progress must be %d of %d, %d of %d found", m.Length(), m.Length(), done, total)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p, err = m.Prefetch(ctx, 0, m.Length())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err != context.Canceled {
+		t.Fatalf("error must be %v, %v found", context.Canceled, err)
+	}
+}
+
+func TestSafeMapping(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSafeMapping(m)
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, len(testBuffer))
+			for {
+				if _, err := s.WriteAt(testBuffer, 0); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := s.ReadAt(buf, 0); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	if _, err := s.Resize(2 * testLength); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if _, ok := err.(*ErrorClosed); !ok {
+			t.Fatalf("expected ErrorClosed, [%v] error found", err)
+		}
+	}
+	if s.Length() != 0 {
+		t.Fatal("length of the closed mapping must be zero")
+	}
+}
+
+func TestCursor(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	c := NewCursor(m, 1, uintptr(len(testBuffer)+1))
+	w := bufio.NewWriter(c)
+	if _, err := w.WriteString("Ж"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(testBuffer); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != io.ErrShortWrite {
+		t.Fatalf("expected io.ErrShortWrite, [%v] error found", err)
+	}
+	if _, err := c.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if r, size, err := c.ReadRune(); err != nil {
+		t.Fatal(err)
+	} else if r != 'Ж' || size != 2 {
+		t.Fatalf("rune must be %q of 2 bytes, %q of %d bytes found", 'Ж', r, size)
+	}
+	buf := make([]byte, len(testBuffer))
+	if n, err := io.ReadFull(c, buf); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, [%v] error found", err)
+	} else if bytes.Compare(buf[:n], testBuffer[:n]) != 0 {
+		t.Fatalf("buffer must be a %q, %q found", testBuffer[:n], buf[:n])
+	}
+	if b, err := m.Cursor().ReadByte(); err != nil {
+		t.Fatal(err)
+	} else if b != 0 {
+		t.Fatalf("byte must be 0, %d found", b)
+	}
+	tx, err := m.Begin(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if r, _, err := tx.Cursor().ReadRune(); err != nil {
+		t.Fatal(err)
+	} else if r != 'Ж' {
+		t.Fatalf("rune must be %q, %q found", 'Ж', r)
+	}
+}
+
+func TestView(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	v, err := m.Slice(10, uintptr(len(testBuffer)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := v.WriteAt(testBuffer, 1); err != io.EOF {
+		t.Fatalf("expected io.EOF, [%v] error found", err)
+	} else if n != len(testBuffer)-1 {
+		t.Fatalf("%d bytes must be written, %d found", len(testBuffer)-1, n)
+	}
+	if _, err := v.WriteAt(testBuffer, int64(len(testBuffer))); err == nil {
+		t.Fatal("expected ErrorInvalidOffset, no error found")
+	}
+	if memory := v.Memory(); len(memory) != len(testBuffer) || cap(memory) != len(testBuffer) {
+		t.Fatalf("view memory must be of %d bytes, %d of %d found", len(testBuffer), len(memory), cap(memory))
+	}
+	tx, err := v.Begin(0, uintptr(len(testBuffer)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(testBuffer))
+	if _, err := m.ReadAt(buf, 10); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(buf, testBuffer) != 0 {
+		t.Fatalf("buffer must be a %q, %v found", testBuffer, buf)
+	}
+	if _, err := m.Slice(int64(testLength)-1, 2); err == nil {
+		t.Fatal("expected ErrorInvalidLength, no error found")
+	}
+}
+
+func TestBulk(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if err := m.Fill(0, 8, 'x'); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.WriteAt(testBuffer, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Move(2, 0, uintptr(len(testBuffer))); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte("HEHELLOx")
+	if bytes.Compare(m.Memory()[:8], expected) != 0 {
+		t.Fatalf("memory must be a %q, %q found", expected, m.Memory()[:8])
+	}
+	a, err := NewAnonymous(testLength, ModeWriteCopy, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, a)
+	if err := a.CopyFrom(m, 1, 0, 8); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := a.Compare(1, m, 0, 8); err != nil {
+		t.Fatal(err)
+	} else if result != 0 {
+		t.Fatalf("memory must be equal, %d found", result)
+	}
+	if err := m.Fill(int64(testLength)-1, 2, 0); err == nil {
+		t.Fatal("expected ErrorInvalidLength, no error found")
+	}
+	tx, err := m.Begin(2, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Fill(7, 1, 'y'); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := tx.Compare(2, m, 2, 6); err != nil {
+		t.Fatal(err)
+	} else if result <= 0 {
+		t.Fatalf("snapshot must be greater, %d found", result)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Memory()[7] != 'y' {
+		t.Fatalf("byte must be %q, %q found", 'y', m.Memory()[7])
+	}
+	r, err := NewAnonymous(testLength, ModeReadOnly, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, r)
+	if err := r.Fill(0, 1, 0); err == nil {
+		t.Fatal("expected ErrorIllegalOperation, no error found")
+	} else if _, ok := err.(*ErrorIllegalOperation); !ok {
+		t.Fatalf("expected ErrorIllegalOperation, [%v] error found", err)
+	}
+}
+
+// testMemoryError is the memory fault error which is provided by the runtime before Go 1.17.
+type testMemoryError struct{}
+
+func (testMemoryError) RuntimeError() {}
+
+func (testMemoryError) Error() string {
+	return memoryErrorMessage
+}
+
+func TestUnknownFaultAddress(t *testing.T) {
+	m, err := makeTestMapping(t, ModeReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testClose(t, m)
+	if err := m.Guard(func() { panic(testMemoryError{}) }); err == nil {
+		t.Fatal("expected ErrorUnsupported, no error found")
+	} else if _, ok := err.(*ErrorUnsupported); !ok {
+		t.Fatalf("expected ErrorUnsupported, [%v] error found", err)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"os"
 	"runtime"
 	"syscall"
-	"unsafe"
 )
 
 const maxInt = int(^uint(0) >> 1)
@@ -13,105 +12,382 @@ const maxInt = int(^uint(0) >> 1)
 // Mapping is a mapping of the file into the memory.
 type Mapping struct {
 	internal
-	hProcess       syscall.Handle
 	hFile          syscall.Handle
 	hMapping       syscall.Handle
+	prot           uint32
+	access         uint32
+	offset         int64
 	alignedAddress uintptr
 	alignedLength  uintptr
 	locked         bool
+	lockedRanges   []lockedRange
+	protections    []protectedRange
 }
 
 // New returns a new mapping of the file into the memory.
 // Actual offset and length may be different than the specified by the reason of aligning to page size.
+// Zero length means that the file is mapped from given offset up to its end.
+// Range of the regular file must not exceed its size, otherwise ErrorBeyondEOF is returned.
+// Given file handle is duplicated, so the file may be closed right after the mapping is created.
 func New(fd uintptr, offset int64, length uintptr, mode Mode, flags Flag) (*Mapping, error) {
+	return NewWithOptions(fd, offset, length, WithMode(mode), WithFlags(flags))
+}
+
+// NewWithOptions returns a new mapping of the file into the memory using given options.
+// See New for details.
+func NewWithOptions(fd uintptr, offset int64, length uintptr, opts ...Option) (*Mapping, error) {
+	o := makeOptions(opts)
+	if o.ranged {
+		return nil, &ErrorInvalidOption{Option: "range"}
+	}
+	if o.exclusive {
+		return nil, &ErrorInvalidOption{Option: "exclusive"}
+	}
+	o.offset = offset
+	o.length = length
+
+	// Separate file handle needed to avoid errors on the mapped file external closing.
+	hProcess, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return nil, os.NewSyscallError("GetCurrentProcess", err)
+	}
+	var hFile syscall.Handle
+	err = syscall.DuplicateHandle(
+		hProcess, syscall.Handle(fd),
+		hProcess, &hFile,
+		0, true, syscall.DUPLICATE_SAME_ACCESS,
+	)
+	if err != nil {
+		return nil, os.NewSyscallError("DuplicateHandle", err)
+	}
+	f := os.NewFile(uintptr(hFile), "")
+	m, err := newMapping(f, o)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// newMapping returns a new mapping of given file into the memory.
+// Returned mapping owns the file and closes it when the mapping is closed.
+func newMapping(f *os.File, o *options) (*Mapping, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	offset, mode, flags := o.offset, o.mode, o.flags
 
 	// Using int64 (off_t) for offset and uintptr (size_t) for the length by reason of compatibility.
 	if offset < 0 {
 		return nil, &ErrorInvalidOffset{Offset: offset}
 	}
+	length, err := mappedLength(f, offset, o.length)
+	if err != nil {
+		return nil, err
+	}
 	if length > uintptr(maxInt) {
 		return nil, &ErrorInvalidLength{Length: length}
 	}
 
 	m := &Mapping{}
-	prot := uint32(syscall.PAGE_READONLY)
-	access := uint32(syscall.FILE_MAP_READ)
+	m.mode = mode
+	m.safe = flags&FlagSafeAccess != 0
+	m.prot = syscall.PAGE_READONLY
+	m.access = syscall.FILE_MAP_READ
 	switch mode {
 	case ModeReadOnly:
 		// NOOP
 	case ModeReadWrite:
-		prot = syscall.PAGE_READWRITE
-		access = syscall.FILE_MAP_WRITE
+		m.prot = syscall.PAGE_READWRITE
+		m.access = syscall.FILE_MAP_WRITE
 		m.writable = true
 	case ModeWriteCopy:
-		prot = syscall.PAGE_WRITECOPY
-		access = syscall.FILE_MAP_COPY
+		m.prot = syscall.PAGE_WRITECOPY
+		m.access = syscall.FILE_MAP_COPY
 		m.writable = true
 	default:
 		return nil, &ErrorInvalidMode{Mode: mode}
 	}
-	if flags&FlagExecutable != 0 {
-		prot <<= 4
-		access |= syscall.FILE_MAP_EXECUTE
-		m.executable = true
+	if flags&FlagGuardPages != 0 {
+		return nil, &ErrorUnsupported{Operation: "guard pages"}
 	}
-
-	// Separate file handle needed to avoid errors on the mapped file external closing.
-	var err error
-	m.hProcess, err = syscall.GetCurrentProcess()
-	if err != nil {
-		return nil, os.NewSyscallError("GetCurrentProcess", err)
+	if flags&FlagHugePages != 0 {
+		return nil, &ErrorUnsupported{Operation: "huge pages"}
 	}
-	err = syscall.DuplicateHandle(
-		m.hProcess, syscall.Handle(fd),
-		m.hProcess, &m.hFile,
-		0, true, syscall.DUPLICATE_SAME_ACCESS,
-	)
-	if err != nil {
-		return nil, os.NewSyscallError("DuplicateHandle", err)
+	if flags&FlagNoReserve != 0 {
+		return nil, &ErrorUnsupported{Operation: "no reserve"}
+	}
+	if flags&FlagExecutable != 0 {
+		m.prot <<= 4
+		m.access |= syscall.FILE_MAP_EXECUTE
+		m.executable = true
 	}
 
-	// Mapping offset must be aligned by the memory page size.
+	// Mapping offset must be aligned by the allocation granularity which is a multiple of the memory page size.
 	pageSize := int64(os.Getpagesize())
 	if pageSize < 0 {
 		return nil, os.NewSyscallError("getpagesize", syscall.EINVAL)
 	}
-	outerOffset := offset / pageSize
-	innerOffset := offset % pageSize
-	m.alignedLength = uintptr(innerOffset) + length
+	granularity := int64(allocationGranularity())
+	innerOffset := offset % granularity
+	m.offset = offset - innerOffset
+	m.pageSize = uintptr(pageSize)
+	m.hFile = syscall.Handle(f.Fd())
+	if err := m.view(uintptr(innerOffset) + length); err != nil {
+		return nil, err
+	}
+	m.file = f
+	m.address = m.alignedAddress + uintptr(innerOffset)
+
+	// Convert the mapping into a byte slice.
+	m.memory = byteSlice(m.address, length)
+	if flags&FlagPopulate != 0 {
+		if err := m.prefetch(m.alignedAddress, m.alignedLength); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+	if flags&FlagLock != 0 {
+		if err := m.Lock(); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+
+	runtime.SetFinalizer(m, (*Mapping).Close)
+	return m, nil
+}
+
+// NewAnonymous returns a new anonymous mapping which is backed by the system paging file.
+// Mapping in the ModeReadWrite mode is shared,
+// mapping in the ModeWriteCopy mode is private.
+// Mapped memory is initialized to zero.
+func NewAnonymous(length uintptr, mode Mode, flags Flag) (*Mapping, error) {
+	if length == 0 || length > uintptr(maxInt) {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	o := &options{mode: mode, flags: flags}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	m := &Mapping{}
+	m.mode = mode
+	m.safe = flags&FlagSafeAccess != 0
+	m.pageSize = uintptr(os.Getpagesize())
+	m.hFile = syscall.InvalidHandle
+	m.prot = syscall.PAGE_READWRITE
+	m.access = syscall.FILE_MAP_READ
+	switch mode {
+	case ModeReadOnly:
+		// NOOP
+	case ModeReadWrite:
+		m.access = syscall.FILE_MAP_WRITE
+		m.writable = true
+	case ModeWriteCopy:
+		m.access = syscall.FILE_MAP_COPY
+		m.writable = true
+	default:
+		return nil, &ErrorInvalidMode{Mode: mode}
+	}
+	if flags&FlagGuardPages != 0 {
+		return nil, &ErrorUnsupported{Operation: "guard pages"}
+	}
+	if flags&FlagHugePages != 0 {
+		return nil, &ErrorUnsupported{Operation: "huge pages"}
+	}
+	if flags&FlagNoReserve != 0 {
+		return nil, &ErrorUnsupported{Operation: "no reserve"}
+	}
+	if flags&FlagExecutable != 0 {
+		m.prot = syscall.PAGE_EXECUTE_READWRITE
+		m.access |= syscall.FILE_MAP_EXECUTE
+		m.executable = true
+	}
+
+	if err := m.view(length); err != nil {
+		return nil, err
+	}
+	m.address = m.alignedAddress
+	m.memory = byteSlice(m.address, length)
+	if flags&FlagPopulate != 0 {
+		if err := m.prefetch(m.alignedAddress, m.alignedLength); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+	if flags&FlagLock != 0 {
+		if err := m.Lock(); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
 
-	maxSize := uint64(outerOffset) + uint64(m.alignedLength)
+	runtime.SetFinalizer(m, (*Mapping).Close)
+	return m, nil
+}
+
+// view creates the file mapping object and maps the view of given aligned length.
+func (m *Mapping) view(alignedLength uintptr) error {
+	var err error
+	maxSize := uint64(m.offset) + uint64(alignedLength)
 	maxSizeHigh := uint32(maxSize >> 32)
 	maxSizeLow := uint32(maxSize & uint64(math.MaxUint32))
-	m.hMapping, err = syscall.CreateFileMapping(m.hFile, nil, prot, maxSizeHigh, maxSizeLow, nil)
+	m.hMapping, err = syscall.CreateFileMapping(m.hFile, nil, m.prot, maxSizeHigh, maxSizeLow, nil)
 	if err != nil {
-		return nil, os.NewSyscallError("CreateFileMapping", err)
+		return os.NewSyscallError("CreateFileMapping", err)
 	}
-	fileOffset := uint64(outerOffset)
+	fileOffset := uint64(m.offset)
 	fileOffsetHigh := uint32(fileOffset >> 32)
 	fileOffsetLow := uint32(fileOffset & uint64(math.MaxUint32))
 	m.alignedAddress, err = syscall.MapViewOfFile(
-		m.hMapping, access,
-		fileOffsetHigh, fileOffsetLow, m.alignedLength,
+		m.hMapping, m.access,
+		fileOffsetHigh, fileOffsetLow, alignedLength,
 	)
 	if err != nil {
-		return nil, os.NewSyscallError("MapViewOfFile", err)
+		syscall.CloseHandle(m.hMapping)
+		return os.NewSyscallError("MapViewOfFile", err)
 	}
-	m.address = m.alignedAddress + uintptr(innerOffset)
+	m.alignedLength = alignedLength
+	return nil
+}
 
-	// Convert the mapping into a byte slice.
-	var sliceHeader struct {
-		data uintptr
-		len  int
-		cap  int
+// Resize changes the mapped memory length.
+// The view of the file is always remapped, so in case of success true is returned
+// and all slices previously returned by Memory become invalid.
+// The underlying file is extended when the mapping in the ModeReadWrite mode grows beyond its end,
+// and it is truncated when the mapping which reached the end of the file shrinks.
+// Mapping in the ModeReadOnly mode can not grow beyond the end of the file,
+// and mapping of the file in the ModeWriteCopy mode can not be resized at all
+// because its private changes would be lost.
+// Lock state of the mapped memory pages is kept.
+// Mapping is kept as it is if it can not be remapped.
+func (m *Mapping) Resize(length uintptr) (bool, error) {
+	if m.memory == nil {
+		return false, &ErrorClosed{}
 	}
-	sliceHeader.data = m.address
-	sliceHeader.len = int(length)
-	sliceHeader.cap = sliceHeader.len
-	m.memory = *(*[]byte)(unsafe.Pointer(&sliceHeader))
+	if length == 0 || length > uintptr(maxInt) {
+		return false, &ErrorInvalidLength{Length: length}
+	}
+	if m.hFile == syscall.InvalidHandle {
+		return m.resizeAnonymous(length)
+	}
+	if m.mode == ModeWriteCopy {
+		return false, &ErrorIllegalOperation{Operation: "resize"}
+	}
+	innerOffset := m.address - m.alignedAddress
+	alignedLength := innerOffset + length
 
-	runtime.SetFinalizer(m, (*Mapping).Close)
-	return m, nil
+	var info syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(m.hFile, &info); err != nil {
+		return false, os.NewSyscallError("GetFileInformationByHandle", err)
+	}
+	fileLength := int64(info.FileSizeHigh)<<32 | int64(info.FileSizeLow)
+	highOffset := m.offset + int64(alignedLength)
+	if m.mode != ModeReadWrite && length > uintptr(len(m.memory)) && highOffset > fileLength {
+		return false, &ErrorBeyondEOF{Offset: m.offset + int64(innerOffset), Length: length, Size: fileLength}
+	}
+
+	if m.writable {
+		if err := m.Sync(); err != nil {
+			return false, err
+		}
+	}
+
+	// The old view is kept until the new one is mapped.
+	hMapping, alignedAddress, oldAlignedLength := m.hMapping, m.alignedAddress, m.alignedLength
+	if err := m.view(alignedLength); err != nil {
+		m.hMapping, m.alignedAddress, m.alignedLength = hMapping, alignedAddress, oldAlignedLength
+		// The file may be already extended by the new file mapping object.
+		if m.mode == ModeReadWrite && highOffset > fileLength {
+			m.truncate(fileLength)
+		}
+		return false, err
+	}
+	m.address = m.alignedAddress + innerOffset
+	m.memory = byteSlice(m.address, length)
+	attributeErr := m.reattribute()
+	if err := m.release(hMapping, alignedAddress, oldAlignedLength); err != nil {
+		return true, err
+	}
+
+	// File can not be truncated below the size of any file mapping object.
+	if m.mode == ModeReadWrite && highOffset < fileLength && m.offset+int64(oldAlignedLength) >= fileLength {
+		if err := m.truncate(highOffset); err != nil {
+			return true, err
+		}
+	}
+	return true, attributeErr
+}
+
+// truncate changes the size of the underlying file.
+func (m *Mapping) truncate(size int64) error {
+	if _, err := syscall.Seek(m.hFile, size, 0); err != nil {
+		return os.NewSyscallError("SetFilePointer", err)
+	}
+	if err := syscall.SetEndOfFile(m.hFile); err != nil {
+		return os.NewSyscallError("SetEndOfFile", err)
+	}
+	return nil
+}
+
+// resizeAnonymous moves the anonymous mapping to the new paging file section of given length.
+func (m *Mapping) resizeAnonymous(length uintptr) (bool, error) {
+	// Memory pages are copied to the new view, so they must be readable.
+	if err := m.unprotect(); err != nil {
+		m.reattribute()
+		return false, err
+	}
+	hMapping, alignedAddress, alignedLength := m.hMapping, m.alignedAddress, m.alignedLength
+	if err := m.view(length); err != nil {
+		m.hMapping, m.alignedAddress, m.alignedLength = hMapping, alignedAddress, alignedLength
+		m.reattribute()
+		return false, err
+	}
+	memory := byteSlice(m.alignedAddress, length)
+	copy(memory, m.memory)
+	m.address = m.alignedAddress
+	m.memory = memory
+	attributeErr := m.reattribute()
+	if err := m.release(hMapping, alignedAddress, alignedLength); err != nil {
+		return true, err
+	}
+	return true, attributeErr
+}
+
+// release unmaps the old view of the remapped mapping and closes its file mapping object.
+func (m *Mapping) release(hMapping syscall.Handle, alignedAddress, alignedLength uintptr) error {
+	if m.locked {
+		if err := syscall.VirtualUnlock(alignedAddress, alignedLength); err != nil {
+			return os.NewSyscallError("VirtualUnlock", err)
+		}
+	}
+	if err := syscall.UnmapViewOfFile(alignedAddress); err != nil {
+		return os.NewSyscallError("UnmapViewOfFile", err)
+	}
+	if err := syscall.CloseHandle(hMapping); err != nil {
+		return os.NewSyscallError("CloseHandle", err)
+	}
+	return nil
+}
+
+// reattribute locks and protects the mapped memory pages again after the attempt to remap them.
+// Memory pages of the new view are locked too if the whole mapping is locked.
+// All the attributes are restored even if some of them fail to be, the first error is returned.
+func (m *Mapping) reattribute() error {
+	var err error
+	if m.locked {
+		if lockErr := syscall.VirtualLock(m.alignedAddress, m.alignedLength); lockErr != nil {
+			err = os.NewSyscallError("VirtualLock", lockErr)
+		}
+	}
+	if lockErr := m.relockRanges(); lockErr != nil && err == nil {
+		err = lockErr
+	}
+	if protectErr := m.reprotect(); protectErr != nil && err == nil {
+		err = protectErr
+	}
+	return err
 }
 
 // Lock locks the mapped memory pages.
@@ -120,11 +396,12 @@ func New(fd uintptr, offset int64, length uintptr, mode Mode, flags Flag) (*Mapp
 // The pages are guaranteed to stay in RAM until later unlocked.
 // It may need to increase process memory limits for operation success.
 // See working set on Windows and rlimit on Linux for details.
+// It fails if any range of the mapped memory pages was locked by LockRange.
 func (m *Mapping) Lock() error {
 	if m.memory == nil {
 		return &ErrorClosed{}
 	}
-	if m.locked {
+	if m.locked || len(m.lockedRanges) > 0 {
 		return &ErrorLocked{}
 	}
 	if err := syscall.VirtualLock(m.alignedAddress, m.alignedLength); err != nil {
@@ -150,6 +427,7 @@ func (m *Mapping) Unlock() error {
 }
 
 // Sync synchronizes this mapping with the underlying file.
+// It does nothing for the anonymous mapping.
 func (m *Mapping) Sync() error {
 	if m.memory == nil {
 		return &ErrorClosed{}
@@ -157,9 +435,41 @@ func (m *Mapping) Sync() error {
 	if !m.writable {
 		return &ErrorIllegalOperation{Operation: "sync"}
 	}
-	if err := syscall.FlushViewOfFile(m.alignedAddress, m.alignedLength); err != nil {
+	if m.hFile == syscall.InvalidHandle {
+		return nil
+	}
+	return m.sync(m.alignedAddress, m.alignedLength, 0)
+}
+
+// SyncRange synchronizes the mapped memory starting from given offset and ends after given length
+// with the underlying file using given flags.
+// Actual range may be different than the specified by the reason of aligning to page size.
+// It does nothing for the anonymous mapping.
+func (m *Mapping) SyncRange(offset int64, length uintptr, flags SyncFlag) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	if !m.writable {
+		return &ErrorIllegalOperation{Operation: "sync"}
+	}
+	address, alignedLength, err := m.alignRange(offset, length)
+	if err != nil {
+		return err
+	}
+	if m.hFile == syscall.InvalidHandle {
+		return nil
+	}
+	return m.sync(address, alignedLength, flags)
+}
+
+// Views of the file are always coherent, so SyncFlagInvalidate is ignored.
+func (m *Mapping) sync(address, length uintptr, flags SyncFlag) error {
+	if err := syscall.FlushViewOfFile(address, length); err != nil {
 		return os.NewSyscallError("FlushViewOfFile", err)
 	}
+	if flags&SyncFlagAsync != 0 {
+		return nil
+	}
 	if err := syscall.FlushFileBuffers(m.hFile); err != nil {
 		return os.NewSyscallError("FlushFileBuffers", err)
 	}
@@ -183,14 +493,19 @@ func (m *Mapping) Close() error {
 			return err
 		}
 	}
+	if err := m.unlockRanges(); err != nil {
+		return err
+	}
 	if err := syscall.UnmapViewOfFile(m.alignedAddress); err != nil {
 		return os.NewSyscallError("UnmapViewOfFile", err)
 	}
 	if err := syscall.CloseHandle(m.hMapping); err != nil {
 		return os.NewSyscallError("CloseHandle", err)
 	}
-	if err := syscall.CloseHandle(m.hFile); err != nil {
-		return os.NewSyscallError("CloseHandle", err)
+	if m.file != nil {
+		if err := m.file.Close(); err != nil {
+			return err
+		}
 	}
 	*m = Mapping{}
 	runtime.SetFinalizer(m, nil)
@@ -0,0 +1,133 @@
+package mmap
+
+// Option is a mapping option.
+type Option func(*options)
+
+type options struct {
+	mode      Mode
+	offset    int64
+	length    uintptr
+	ranged    bool
+	flags     Flag
+	hint      uintptr
+	exclusive bool
+	placed    bool
+	err       error
+}
+
+func makeOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// validate checks that the options are valid together.
+func (o *options) validate() error {
+	if o.err != nil {
+		return o.err
+	}
+	if o.mode < ModeReadOnly || o.mode > ModeWriteCopy {
+		return &ErrorInvalidMode{Mode: o.mode}
+	}
+	if o.flags&^flagMask != 0 {
+		return &ErrorInvalidFlags{Flags: o.flags}
+	}
+	if o.flags&FlagGuardPages != 0 && o.flags&FlagHugePages != 0 {
+		return &ErrorInvalidFlags{Flags: o.flags}
+	}
+	if o.flags&FlagLock != 0 && o.flags&FlagNoReserve != 0 {
+		return &ErrorInvalidFlags{Flags: o.flags}
+	}
+	return nil
+}
+
+// WithMode specifies the mapping mode.
+// By default the mapping is created in the ModeReadOnly mode.
+func WithMode(mode Mode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithRange specifies the mapped range of the file.
+// Zero length means that the file is mapped up to its end.
+// By default the whole file is mapped.
+// Not valid for NewWithOptions which takes the range explicitly.
+func WithRange(offset int64, length uintptr) Option {
+	return func(o *options) {
+		o.offset = offset
+		o.length = length
+		o.ranged = true
+	}
+}
+
+// WithFlags specifies the mapping flags.
+// Flags are added to the ones which are specified by other options.
+func WithFlags(flags Flag) Option {
+	return func(o *options) {
+		o.flags |= flags
+	}
+}
+
+// WithPopulate prefaults the mapped memory pages.
+// See FlagPopulate for details.
+func WithPopulate() Option {
+	return WithFlags(FlagPopulate)
+}
+
+// WithHugePages maps the memory using the huge pages of given size.
+// Zero size means the default huge page size.
+// Only the huge pages of 2MB and 1GB may be requested explicitly.
+// See FlagHugePages for details.
+func WithHugePages(size uintptr) Option {
+	return func(o *options) {
+		switch size {
+		case 0:
+			o.flags |= FlagHugePages
+		case 2 << 20:
+			o.flags |= FlagHugePages2MB
+		case 1 << 30:
+			o.flags |= FlagHugePages1GB
+		default:
+			o.err = &ErrorInvalidOption{Option: "huge pages"}
+		}
+	}
+}
+
+// WithAddressHint specifies the preferred address of the mapping.
+// The system may place the mapping elsewhere, see Address for the actual one.
+// Ignored on Windows.
+func WithAddressHint(address uintptr) Option {
+	return func(o *options) {
+		o.hint = address
+	}
+}
+
+// WithLock locks the mapped memory pages right after the mapping is created.
+// See FlagLock for details.
+func WithLock() Option {
+	return WithFlags(FlagLock)
+}
+
+// WithNoReserve does not reserve the swap space for the mapped memory.
+// See FlagNoReserve for details.
+func WithNoReserve() Option {
+	return WithFlags(FlagNoReserve)
+}
+
+// WithGuardPages surrounds the mapped memory with inaccessible guard pages.
+// See FlagGuardPages for details.
+func WithGuardPages() Option {
+	return WithFlags(FlagGuardPages)
+}
+
+// WithExclusive requires the shared memory object to be created by OpenShared.
+// By default the existing object is opened.
+// Not valid for other constructors.
+func WithExclusive() Option {
+	return func(o *options) {
+		o.exclusive = true
+	}
+}
@@ -0,0 +1,81 @@
+package mmap
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// prefetchChunkSize is the number of bytes which are prefetched at once between the cancellation checks.
+const prefetchChunkSize = 4 << 20
+
+// Prefetcher is a background warm-up of the mapped memory pages.
+type Prefetcher struct {
+	done     uint64
+	total    uintptr
+	finished chan struct{}
+	err      error
+}
+
+// Prefetch starts warming up the mapped memory pages starting from given offset and ends after given length
+// in the background, so that the subsequent access to them does not wait for reading from the disk.
+// Actual range may be different than the specified by the reason of aligning to page size.
+// Prefetching stops when given context is done.
+// Mapping must not be resized or closed until the prefetching is finished.
+func (m *Mapping) Prefetch(ctx context.Context, offset int64, length uintptr) (*Prefetcher, error) {
+	if m.memory == nil {
+		return nil, &ErrorClosed{}
+	}
+	address, alignedLength, err := m.alignRange(offset, length)
+	if err != nil {
+		return nil, err
+	}
+	p := &Prefetcher{
+		total:    alignedLength,
+		finished: make(chan struct{}),
+	}
+	go func() {
+		defer close(p.finished)
+		p.err = m.prefetchRange(ctx, p, address, alignedLength)
+	}()
+	return p, nil
+}
+
+// prefetchRange prefetches the memory pages of given address and length chunk by chunk.
+// Memory pages are never touched, so the prefetching does not fault
+// even if the underlying file was truncated or the memory pages are not readable.
+func (m *Mapping) prefetchRange(ctx context.Context, p *Prefetcher, address, length uintptr) error {
+	for done := uintptr(0); done < length; {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		n := length - done
+		if n > prefetchChunkSize {
+			n = prefetchChunkSize
+		}
+		if err := m.prefetch(address+done, n); err != nil {
+			return err
+		}
+		done += n
+		atomic.StoreUint64(&p.done, uint64(done))
+	}
+	return nil
+}
+
+// Progress returns the number of prefetched bytes and the total number of bytes to prefetch.
+func (p *Prefetcher) Progress() (uintptr, uintptr) {
+	return uintptr(atomic.LoadUint64(&p.done)), p.total
+}
+
+// Done returns a channel which is closed when the prefetching is finished.
+func (p *Prefetcher) Done() <-chan struct{} {
+	return p.finished
+}
+
+// Wait waits for the prefetching is finished and returns its error if any.
+// Error of the context is returned if the prefetching was stopped.
+func (p *Prefetcher) Wait() error {
+	<-p.finished
+	return p.err
+}
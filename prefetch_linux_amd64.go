@@ -0,0 +1,29 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+func readahead(fd uintptr, offset int64, count uintptr) error {
+	_, _, err := syscall.Syscall(syscall.SYS_READAHEAD, fd, uintptr(offset), count)
+	if err != 0 {
+		return errno(err)
+	}
+	return nil
+}
+
+// Pages of the regular file are read into the page cache,
+// pages of other mappings are advised to be needed soon.
+func (m *Mapping) prefetch(address, length uintptr) error {
+	if m.file != nil {
+		err := readahead(m.file.Fd(), m.offset+int64(address-m.alignedAddress), length)
+		if err == nil {
+			return nil
+		}
+		if err != syscall.EINVAL {
+			return os.NewSyscallError("readahead", err)
+		}
+	}
+	return m.advise(address, length, AdviceWillNeed)
+}
@@ -0,0 +1,11 @@
+package mmap
+
+// Prefetching is the best effort and does nothing on the systems which do not support it.
+func (m *Mapping) prefetch(address, length uintptr) error {
+	if err := m.advise(address, length, AdviceWillNeed); err != nil {
+		if _, ok := err.(*ErrorUnsupported); !ok {
+			return err
+		}
+	}
+	return nil
+}
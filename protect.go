@@ -0,0 +1,184 @@
+package mmap
+
+// Protection is a memory protection flags.
+type Protection int
+
+const (
+	// Memory pages may not be accessed.
+	ProtectionNone Protection = 0
+
+	// Memory pages may be read.
+	ProtectionRead Protection = 0x1
+
+	// Memory pages may be written.
+	// Implies ProtectionRead.
+	ProtectionWrite Protection = 0x2
+
+	// Memory pages may be executed.
+	// Implies ProtectionRead.
+	ProtectionExecute Protection = 0x4
+)
+
+// protectedRange is a range of the memory pages with the same protection
+// relatively to the aligned mapping address.
+type protectedRange struct {
+	offset uintptr
+	length uintptr
+	prot   Protection
+}
+
+// Protect changes the protection of the mapped memory pages starting from given offset and ends after given length.
+// Actual range may be different than the specified by the reason of aligning to page size,
+// all pages that contain a part of given range are protected.
+// Protection may not exceed the one which is defined by the mapping mode and flags.
+func (m *Mapping) Protect(offset int64, length uintptr, prot Protection) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	if m.sealed {
+		return &ErrorIllegalOperation{Operation: "protect"}
+	}
+	if prot != ProtectionNone {
+		prot |= ProtectionRead
+	}
+	if prot&^m.maxProtection() != 0 {
+		return &ErrorIllegalOperation{Operation: "protect"}
+	}
+	address, alignedLength, err := m.alignRange(offset, length)
+	if err != nil {
+		return err
+	}
+
+	// Protection is changed for the whole last page, but not beyond the mapped memory.
+	alignedLength = (alignedLength + m.pageSize - 1) &^ (m.pageSize - 1)
+	if high := address - m.alignedAddress + alignedLength; high > m.alignedLength {
+		alignedLength -= high - m.alignedLength
+	}
+	if err := m.protect(address, alignedLength, prot); err != nil {
+		return err
+	}
+	m.setProtection(address-m.alignedAddress, alignedLength, prot)
+	return nil
+}
+
+// Protection returns the protection of the mapped memory pages starting from given offset and ends after given length.
+// If the memory pages are protected differently then only common protection flags are returned.
+func (m *Mapping) Protection(offset int64, length uintptr) (Protection, error) {
+	if m.memory == nil {
+		return ProtectionNone, &ErrorClosed{}
+	}
+	if _, _, err := m.alignRange(offset, length); err != nil {
+		return ProtectionNone, err
+	}
+	return m.protection(offset, length), nil
+}
+
+// maxProtection returns the protection which is defined by the mapping mode and flags.
+func (m *Mapping) maxProtection() Protection {
+	prot := ProtectionRead
+	if m.writable {
+		prot |= ProtectionWrite
+	}
+	if m.executable {
+		prot |= ProtectionExecute
+	}
+	return prot
+}
+
+// protection returns the common protection of the mapped memory starting from given offset and ends after given length.
+// Given range must be valid.
+func (m *Mapping) protection(offset int64, length uintptr) Protection {
+	prot := m.maxProtection()
+	if m.protections == nil || length == 0 {
+		return prot
+	}
+	low := m.address - m.alignedAddress + uintptr(offset)
+	high := low + length
+	for _, r := range m.protections {
+		if low < r.offset+r.length && r.offset < high {
+			prot &= r.prot
+		}
+	}
+	return prot
+}
+
+// setProtection records the protection of the memory pages
+// starting from given offset relatively to the aligned mapping address and ends after given length.
+func (m *Mapping) setProtection(offset, length uintptr, prot Protection) {
+	if m.protections == nil {
+		m.protections = []protectedRange{{offset: 0, length: m.alignedLength, prot: m.maxProtection()}}
+	}
+	high := offset + length
+	ranges := make([]protectedRange, 0, len(m.protections)+2)
+	for _, r := range m.protections {
+		rHigh := r.offset + r.length
+		if rHigh <= offset || r.offset >= high {
+			ranges = append(ranges, r)
+			continue
+		}
+		if r.offset < offset {
+			ranges = append(ranges, protectedRange{offset: r.offset, length: offset - r.offset, prot: r.prot})
+		}
+		if r.offset <= offset {
+			ranges = append(ranges, protectedRange{offset: offset, length: length, prot: prot})
+		}
+		if rHigh > high {
+			ranges = append(ranges, protectedRange{offset: high, length: rHigh - high, prot: r.prot})
+		}
+	}
+
+	// Adjacent ranges with the same protection are merged.
+	m.protections = ranges[:0]
+	for _, r := range ranges {
+		if n := len(m.protections); n > 0 && m.protections[n-1].prot == r.prot {
+			m.protections[n-1].length += r.length
+			continue
+		}
+		m.protections = append(m.protections, r)
+	}
+	if len(m.protections) == 1 && m.protections[0].prot == m.maxProtection() {
+		m.protections = nil
+	}
+}
+
+// unprotect temporarily restores the default protection of all the mapped memory pages.
+// Protection of the memory pages is kept to be restored by reprotect.
+func (m *Mapping) unprotect() error {
+	if m.protections == nil {
+		return nil
+	}
+	return m.protect(m.alignedAddress, m.alignedLength, m.maxProtection())
+}
+
+// reprotect restores the protection of the memory pages after the mapping is remapped.
+// Memory pages which are no longer mapped are forgotten, new ones are protected by default.
+func (m *Mapping) reprotect() error {
+	if m.protections == nil {
+		return nil
+	}
+	var mapped uintptr
+	ranges := m.protections[:0]
+	for _, r := range m.protections {
+		if r.offset >= m.alignedLength {
+			break
+		}
+		if r.offset+r.length > m.alignedLength {
+			r.length = m.alignedLength - r.offset
+		}
+		mapped = r.offset + r.length
+		ranges = append(ranges, r)
+	}
+	m.protections = ranges
+	if mapped < m.alignedLength {
+		m.setProtection(mapped, m.alignedLength-mapped, m.maxProtection())
+	}
+	if m.protections == nil {
+		return m.protect(m.alignedAddress, m.alignedLength, m.maxProtection())
+	}
+	for _, r := range m.protections {
+		if err := m.protect(m.alignedAddress+r.offset, r.length, r.prot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+func (m *Mapping) protect(address, length uintptr, prot Protection) error {
+	mprot := syscall.PROT_NONE
+	if prot&ProtectionRead != 0 {
+		mprot |= syscall.PROT_READ
+	}
+	if prot&ProtectionWrite != 0 {
+		mprot |= syscall.PROT_WRITE
+	}
+	if prot&ProtectionExecute != 0 {
+		mprot |= syscall.PROT_EXEC
+	}
+	if err := mprotect(address, length, mprot); err != nil {
+		return os.NewSyscallError("mprotect", err)
+	}
+	return nil
+}
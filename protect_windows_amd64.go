@@ -0,0 +1,32 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const pageNoAccess = 0x1
+
+func (m *Mapping) protect(address, length uintptr, prot Protection) error {
+	var value uint32
+	switch {
+	case prot == ProtectionNone:
+		value = pageNoAccess
+	case prot&ProtectionWrite == 0:
+		value = syscall.PAGE_READONLY
+	case m.mode == ModeWriteCopy:
+		value = syscall.PAGE_WRITECOPY
+	default:
+		value = syscall.PAGE_READWRITE
+	}
+	if prot&ProtectionExecute != 0 {
+		value <<= 4
+	}
+	var old uint32
+	r, _, err := procVirtualProtect.Call(address, length, uintptr(value), uintptr(unsafe.Pointer(&old)))
+	if r == 0 {
+		return os.NewSyscallError("VirtualProtect", err)
+	}
+	return nil
+}
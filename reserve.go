@@ -0,0 +1,41 @@
+package mmap
+
+// Reservation is a reserved range of the address space which is not accessible
+// until the files are mapped at the fixed places within it.
+type Reservation struct {
+	address uintptr
+	length  uintptr
+	placed  []*Mapping
+}
+
+// Address returns the starting address of the reserved range.
+func (r *Reservation) Address() uintptr {
+	return r.address
+}
+
+// Length returns the reserved range length in bytes.
+func (r *Reservation) Length() uintptr {
+	return r.length
+}
+
+// overlap returns the mapping which is placed within the range of given offset and length if any.
+func (r *Reservation) overlap(offset, length uintptr) *Mapping {
+	low := r.address + offset
+	high := low + length
+	for _, m := range r.placed {
+		if low < m.alignedAddress+m.placedLength() && m.alignedAddress < high {
+			return m
+		}
+	}
+	return nil
+}
+
+// remove forgets given placed mapping.
+func (r *Reservation) remove(m *Mapping) {
+	for i, placed := range r.placed {
+		if placed == m {
+			r.placed = append(r.placed[:i], r.placed[i+1:]...)
+			return
+		}
+	}
+}
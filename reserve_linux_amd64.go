@@ -0,0 +1,111 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+// Reserve reserves the range of the address space of given length.
+// Actual length may be different than the specified by the reason of aligning to page size.
+// The reserved memory is not accessible and does not consume any storage.
+func Reserve(length uintptr) (*Reservation, error) {
+	pageSize := uintptr(os.Getpagesize())
+	if length == 0 || length > uintptr(maxInt)-pageSize {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	length = (length + pageSize - 1) &^ (pageSize - 1)
+	address, err := mmap(0, length, syscall.PROT_NONE, reservedFlags, ^uintptr(0), 0)
+	if err != nil {
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	return &Reservation{address: address, length: length}, nil
+}
+
+// reservedFlags are the mmap flags of the reserved memory.
+const reservedFlags = syscall.MAP_PRIVATE | syscall.MAP_ANONYMOUS | syscall.MAP_NORESERVE
+
+// MapAt maps the file into the reserved memory starting from given offset.
+// Offset within the reservation and the file offset must be aligned by the memory page size.
+// It fails with ErrorOverlap if the range is already used by another mapping.
+// The returned mapping can not be resized and its memory is reserved again when it is closed.
+// Given file descriptor is duplicated, so the file may be closed right after the mapping is created.
+func (r *Reservation) MapAt(offset uintptr, fd uintptr, fileOffset int64, length uintptr, mode Mode) (*Mapping, error) {
+	if r.address == 0 {
+		return nil, &ErrorClosed{}
+	}
+	pageSize := uintptr(os.Getpagesize())
+	if offset%pageSize != 0 || offset >= r.length {
+		return nil, &ErrorInvalidOffset{Offset: int64(offset)}
+	}
+	if fileOffset < 0 || fileOffset%int64(pageSize) != 0 {
+		return nil, &ErrorInvalidOffset{Offset: fileOffset}
+	}
+	alignedLength := (length + pageSize - 1) &^ (pageSize - 1)
+	if length == 0 || alignedLength < length || alignedLength > r.length-offset {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	if placed := r.overlap(offset, alignedLength); placed != nil {
+		return nil, &ErrorOverlap{Offset: offset, Length: alignedLength, Address: placed.alignedAddress}
+	}
+
+	dupFd, err := syscall.Dup(int(fd))
+	if err != nil {
+		return nil, os.NewSyscallError("dup", err)
+	}
+	syscall.CloseOnExec(dupFd)
+	f := os.NewFile(uintptr(dupFd), "")
+
+	// Reserved memory is replaced at once, so that the range is never left free to be taken by another mapping.
+	address := r.address + offset
+	m, err := newMapping(f, &options{
+		mode:   mode,
+		offset: fileOffset,
+		length: length,
+		hint:   address,
+		placed: true,
+	})
+	if err != nil {
+		f.Close()
+
+		// Failed fixed mapping may leave the range unmapped.
+		if _, reserveErr := mmap(address, alignedLength, syscall.PROT_NONE, reservedFlags|syscall.MAP_FIXED, ^uintptr(0), 0); reserveErr != nil {
+			return nil, os.NewSyscallError("mmap", reserveErr)
+		}
+		return nil, err
+	}
+	m.reservation = r
+	r.placed = append(r.placed, m)
+	return m, nil
+}
+
+// Release closes all the mappings placed within this reservation and releases the reserved memory.
+func (r *Reservation) Release() error {
+	if r.address == 0 {
+		return &ErrorClosed{}
+	}
+	for len(r.placed) > 0 {
+		if err := r.placed[0].Close(); err != nil {
+			return err
+		}
+	}
+	if err := munmap(r.address, r.length); err != nil {
+		return os.NewSyscallError("munmap", err)
+	}
+	*r = Reservation{}
+	return nil
+}
+
+// placedLength returns the length of the reserved memory which is used by this mapping.
+func (m *Mapping) placedLength() uintptr {
+	return (m.alignedLength + m.pageSize - 1) &^ (m.pageSize - 1)
+}
+
+// unplace reserves the memory of this mapping again.
+func (m *Mapping) unplace() error {
+	_, err := mmap(m.alignedAddress, m.placedLength(), syscall.PROT_NONE, reservedFlags|syscall.MAP_FIXED, ^uintptr(0), 0)
+	if err != nil {
+		return os.NewSyscallError("mmap", err)
+	}
+	m.reservation.remove(m)
+	return nil
+}
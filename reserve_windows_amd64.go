@@ -0,0 +1,21 @@
+package mmap
+
+// Reserve is not supported on Windows.
+func Reserve(length uintptr) (*Reservation, error) {
+	return nil, &ErrorUnsupported{Operation: "reserve"}
+}
+
+// MapAt is not supported on Windows.
+func (r *Reservation) MapAt(offset uintptr, fd uintptr, fileOffset int64, length uintptr, mode Mode) (*Mapping, error) {
+	return nil, &ErrorUnsupported{Operation: "reserve"}
+}
+
+// Release is not supported on Windows.
+func (r *Reservation) Release() error {
+	return &ErrorUnsupported{Operation: "reserve"}
+}
+
+// Mappings can not be placed on Windows.
+func (m *Mapping) placedLength() uintptr {
+	return m.alignedLength
+}
@@ -0,0 +1,44 @@
+package mmap
+
+import "os"
+
+// Resident reports which of the mapped memory pages are resident in RAM.
+// Memory pages are numbered from the page which contains the mapping address,
+// so the byte at given offset of the mapped memory is in the page
+// with index (Address()+offset)/pageSize - Address()/pageSize.
+func (m *Mapping) Resident() ([]bool, error) {
+	if m.memory == nil {
+		return nil, &ErrorClosed{}
+	}
+	return m.resident(m.alignedAddress, m.alignedLength)
+}
+
+// ResidentRatio returns the ratio of the mapped memory pages which are resident in RAM
+// starting from given offset and ends after given length.
+// Actual range may be different than the specified by the reason of aligning to page size.
+func (m *Mapping) ResidentRatio(offset int64, length uintptr) (float64, error) {
+	if m.memory == nil {
+		return 0, &ErrorClosed{}
+	}
+	address, alignedLength, err := m.alignRange(offset, length)
+	if err != nil {
+		return 0, err
+	}
+	pages, err := m.resident(address, alignedLength)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, resident := range pages {
+		if resident {
+			n++
+		}
+	}
+	return float64(n) / float64(len(pages)), nil
+}
+
+// pageCount returns the number of memory pages which contain given number of bytes starting from the page boundary.
+func pageCount(length uintptr) int {
+	pageSize := uintptr(os.Getpagesize())
+	return int((length + pageSize - 1) / pageSize)
+}
@@ -0,0 +1,27 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func mincore(addr, length uintptr, vec []byte) error {
+	_, _, err := syscall.Syscall(syscall.SYS_MINCORE, addr, length, uintptr(unsafe.Pointer(&vec[0])))
+	if err != 0 {
+		return errno(err)
+	}
+	return nil
+}
+
+func (m *Mapping) resident(address, length uintptr) ([]bool, error) {
+	vec := make([]byte, pageCount(length))
+	if err := mincore(address, length, vec); err != nil {
+		return nil, os.NewSyscallError("mincore", err)
+	}
+	pages := make([]bool, len(vec))
+	for i, v := range vec {
+		pages[i] = v&0x1 != 0
+	}
+	return pages, nil
+}
@@ -0,0 +1,36 @@
+package mmap
+
+import (
+	"os"
+	"unsafe"
+)
+
+// workingSetExInformation is the PSAPI_WORKING_SET_EX_INFORMATION structure.
+type workingSetExInformation struct {
+	virtualAddress    uintptr
+	virtualAttributes uintptr
+}
+
+func (m *Mapping) resident(address, length uintptr) ([]bool, error) {
+	if err := procQueryWorkingSetEx.Find(); err != nil {
+		return nil, &ErrorUnsupported{Operation: "resident"}
+	}
+	pageSize := uintptr(os.Getpagesize())
+	info := make([]workingSetExInformation, pageCount(length))
+	for i := range info {
+		info[i].virtualAddress = address + uintptr(i)*pageSize
+	}
+	r, _, err := procQueryWorkingSetEx.Call(
+		currentProcess,
+		uintptr(unsafe.Pointer(&info[0])),
+		uintptr(len(info))*unsafe.Sizeof(info[0]),
+	)
+	if r == 0 {
+		return nil, os.NewSyscallError("QueryWorkingSetEx", err)
+	}
+	pages := make([]bool, len(info))
+	for i := range info {
+		pages[i] = info[i].virtualAttributes&0x1 != 0
+	}
+	return pages, nil
+}
@@ -0,0 +1,55 @@
+package mmap
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Guard calls given function and returns ErrorFault if it faults accessing the mapped memory
+// instead of crashing the program, e.g. when the underlying file was truncated by another process.
+// Faults outside of the mapped memory are not recovered.
+// Fault address is known only since Go 1.17, so ErrorUnsupported is returned on any memory fault by the earlier versions.
+func (m *Mapping) Guard(fn func()) error {
+	return m.safely(true, fn)
+}
+
+// safely calls given function, recovering the mapped memory access fault if safe is true.
+func (m *internal) safely(safe bool, fn func()) (err error) {
+	if !safe {
+		fn()
+		return nil
+	}
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			err = m.fault(r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// memoryErrorMessage is the message of the memory fault error which is provided by the runtime before Go 1.17.
+const memoryErrorMessage = "runtime error: invalid memory address or nil pointer dereference"
+
+// fault converts given recovered value into ErrorFault
+// or panics again if it is not a fault of the mapped memory access.
+func (m *internal) fault(r interface{}) error {
+	// Fault address is provided by the runtime since Go 1.17.
+	if err, ok := r.(interface {
+		runtime.Error
+		Addr() uintptr
+	}); ok {
+		address := err.Addr()
+		if address >= m.address && address < m.address+uintptr(len(m.memory)) {
+			return &ErrorFault{Offset: int64(address - m.address)}
+		}
+		panic(r)
+	}
+
+	// Fault of the mapped memory access can not be told apart from any other one without its address.
+	if err, ok := r.(runtime.Error); ok && err.Error() == memoryErrorMessage {
+		return &ErrorUnsupported{Operation: "safe access"}
+	}
+	panic(r)
+}
@@ -0,0 +1,55 @@
+package mmap
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+const sysMseal = 462
+
+var (
+	msealOnce      sync.Once
+	msealSupported bool
+)
+
+func mseal(addr, length uintptr) error {
+	_, _, err := syscall.Syscall(sysMseal, addr, length, 0)
+	if err != 0 {
+		return errno(err)
+	}
+	return nil
+}
+
+// sealSupported probes whether the memory sealing is supported by the kernel.
+// Sealing of the empty range does nothing on the kernels which support it.
+func sealSupported() bool {
+	msealOnce.Do(func() {
+		msealSupported = mseal(0, 0) == nil
+	})
+	return msealSupported
+}
+
+// Seal seals the mapped memory pages including the guard pages,
+// so that nothing in the process can unmap, remap or change the protection of them anymore.
+// Sealed mapping can not be closed, resized, protected or discarded,
+// its memory is released only when the process exits.
+// ErrorUnsupported is returned if the kernel does not support the memory sealing.
+func (m *Mapping) Seal() error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	if m.sealed {
+		return nil
+	}
+	if !sealSupported() {
+		return &ErrorUnsupported{Operation: "seal"}
+	}
+	if err := mseal(m.alignedAddress-m.guard, m.guardedLength(m.alignedLength)); err != nil {
+		return os.NewSyscallError("mseal", err)
+	}
+	m.sealed = true
+	runtime.SetFinalizer(m, nil)
+	return nil
+}
@@ -0,0 +1,6 @@
+package mmap
+
+// Seal is not supported on Windows.
+func (m *Mapping) Seal() error {
+	return &ErrorUnsupported{Operation: "seal"}
+}
@@ -0,0 +1,18 @@
+package mmap
+
+// Freeze makes all the mapped memory pages read-only.
+// See Thaw to make them writable again.
+func (m *Mapping) Freeze() error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	return m.Protect(0, uintptr(len(m.memory)), ProtectionRead)
+}
+
+// Thaw restores the protection of all the mapped memory pages which is defined by the mapping mode and flags.
+func (m *Mapping) Thaw() error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	return m.Protect(0, uintptr(len(m.memory)), m.maxProtection())
+}
@@ -0,0 +1,42 @@
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+const madvWipeOnFork = 0x12
+
+// NewSecure returns a new anonymous private mapping for the secrets which is surrounded by the guard pages.
+// Mapped memory is locked, excluded from the core dumps and zeroed in the child processes after fork.
+// Mapped memory is overwritten with zeros when the mapping is closed.
+// See Freeze and Thaw to switch between the read-only and read-write access.
+func NewSecure(length uintptr) (*Mapping, error) {
+	m, err := NewAnonymous(length, ModeWriteCopy, FlagGuardPages|FlagLock)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.advise(m.alignedAddress, m.alignedLength, AdviceDontDump); err != nil {
+		m.Close()
+		return nil, err
+	}
+	if err := madvise(m.alignedAddress, m.alignedLength, madvWipeOnFork); err != nil {
+		m.Close()
+		if err == syscall.EINVAL {
+			return nil, &ErrorUnsupported{Operation: "wipe on fork"}
+		}
+		return nil, os.NewSyscallError("madvise", err)
+	}
+	m.secure = true
+	return m, nil
+}
+
+// wipe overwrites the mapped memory with zeros regardless of its protection.
+func (m *Mapping) wipe() error {
+	if err := m.unprotect(); err != nil {
+		return err
+	}
+	m.protections = nil
+	fill(m.memory, 0)
+	return nil
+}
@@ -0,0 +1,6 @@
+package mmap
+
+// NewSecure is not supported on Windows.
+func NewSecure(length uintptr) (*Mapping, error) {
+	return nil, &ErrorUnsupported{Operation: "secure memory"}
+}
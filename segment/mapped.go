@@ -23,25 +23,11 @@ func NewMapped(m *mmap.Mapping) *MappedSegment {
 // NewFile prepares a data segment file, calls init function if file was just created
 // and returns a new data segment on top of the mapping of file into the memory.
 func NewFile(name string, perm os.FileMode, size uintptr, init func(seg *MappedSegment) error) (*MappedSegment, error) {
-	m, created, err := func() (*mmap.Mapping, bool, error) {
-		created := false
-		if _, err := os.Stat(name); err != nil && os.IsNotExist(err) {
-			created = true
-		}
-		f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, perm)
-		if err != nil {
-			return nil, false, err
-		}
-		defer f.Close()
-		if err := f.Truncate(int64(size)); err != nil {
-			return nil, false, err
-		}
-		m, err := mmap.New(f.Fd(), 0, size, mmap.ModeReadWrite, 0)
-		if err != nil {
-			return nil, false, err
-		}
-		return m, created, nil
-	}()
+	created := false
+	if _, err := os.Stat(name); err != nil && os.IsNotExist(err) {
+		created = true
+	}
+	m, err := mmap.Create(name, int64(size), perm)
 	if err != nil {
 		return nil, err
 	}
@@ -73,3 +59,30 @@ func (seg *MappedSegment) Begin(offset int64, length uintptr) (*MappedSegmentTra
 		Segment:     New(tx),
 	}, nil
 }
+
+// ViewSegment is a data segment on top of the mapping view.
+// Offsets of the segment are relative to the view start.
+type ViewSegment struct {
+	*mmap.View
+	*Segment
+}
+
+// NewView returns a new data segment on top of the mapping view.
+func NewView(v *mmap.View) *ViewSegment {
+	return &ViewSegment{
+		View:    v,
+		Segment: New(v),
+	}
+}
+
+// Begin starts a transaction.
+func (seg *ViewSegment) Begin(offset int64, length uintptr) (*MappedSegmentTransaction, error) {
+	tx, err := seg.View.Begin(offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return &MappedSegmentTransaction{
+		Transaction: tx,
+		Segment:     New(tx),
+	}, nil
+}
@@ -0,0 +1,13 @@
+package mmap
+
+// SharedInfo describes the named shared memory object.
+type SharedInfo struct {
+	// Name specifies the object name.
+	Name string
+	// Size specifies the object size in bytes.
+	Size int64
+	// UID specifies the user ID of the object owner.
+	UID int
+	// GID specifies the group ID of the object owner.
+	GID int
+}
@@ -0,0 +1,123 @@
+package mmap
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// sharedDir is the directory of the named shared memory objects.
+const sharedDir = "/dev/shm"
+
+// sharedPath returns the path of the named shared memory object.
+// Name may be prefixed with a slash and must not contain other slashes.
+func sharedPath(name string) (string, error) {
+	name = strings.TrimPrefix(name, "/")
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", &ErrorInvalidName{Name: name}
+	}
+	return filepath.Join(sharedDir, name), nil
+}
+
+// OpenShared opens the named shared memory object and maps it into the memory.
+// If size is positive the object is created if it does not exist and it is extended to given size if it is smaller.
+// Zero size means that the object must already exist.
+// Creation of the object fails if it already exists when WithExclusive option is specified.
+// The whole object is mapped unless another range is specified by WithRange option.
+// The object is owned by the returned mapping and is closed, but not removed, when the mapping is closed.
+// Object created by this call is removed if it can not be mapped.
+// See UnlinkShared to remove the object.
+func OpenShared(name string, size int64, mode Mode, perm os.FileMode, opts ...Option) (*Mapping, error) {
+	if size < 0 {
+		return nil, &ErrorInvalidLength{Length: uintptr(size)}
+	}
+	path, err := sharedPath(name)
+	if err != nil {
+		return nil, err
+	}
+	o := makeOptions(opts)
+	o.mode = mode
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	flag := os.O_RDONLY
+	if mode == ModeReadWrite || size > 0 {
+		flag = os.O_RDWR
+	}
+	var f *os.File
+	created := false
+	if size > 0 {
+		f, err = os.OpenFile(path, flag|os.O_CREATE|os.O_EXCL, perm)
+		created = err == nil
+		if err != nil && os.IsExist(err) && !o.exclusive {
+			f, err = os.OpenFile(path, flag, 0)
+		}
+	} else {
+		if o.exclusive {
+			return nil, &ErrorInvalidLength{Length: 0}
+		}
+		f, err = os.OpenFile(path, flag, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := openShared(f, size, o)
+	if err != nil {
+		f.Close()
+		if created {
+			os.Remove(path)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// openShared extends the opened shared memory object to given size if it is smaller and maps it into the memory.
+func openShared(f *os.File, size int64, o *options) (*Mapping, error) {
+	if size > 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() < size {
+			if err := f.Truncate(size); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return newMapping(f, o)
+}
+
+// UnlinkShared removes the named shared memory object.
+// The object memory is freed when all its mappings are closed.
+func UnlinkShared(name string) error {
+	path, err := sharedPath(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// ListShared returns the named shared memory objects which are visible to the current process.
+func ListShared() ([]SharedInfo, error) {
+	infos, err := ioutil.ReadDir(sharedDir)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]SharedInfo, 0, len(infos))
+	for _, info := range infos {
+		// Named semaphores are stored in the same directory.
+		if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), "sem.") {
+			continue
+		}
+		shared := SharedInfo{Name: info.Name(), Size: info.Size()}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			shared.UID = int(stat.Uid)
+			shared.GID = int(stat.Gid)
+		}
+		list = append(list, shared)
+	}
+	return list, nil
+}
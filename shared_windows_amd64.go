@@ -0,0 +1,18 @@
+package mmap
+
+import "os"
+
+// OpenShared is not supported on Windows.
+func OpenShared(name string, size int64, mode Mode, perm os.FileMode, opts ...Option) (*Mapping, error) {
+	return nil, &ErrorUnsupported{Operation: "shared memory"}
+}
+
+// UnlinkShared is not supported on Windows.
+func UnlinkShared(name string) error {
+	return &ErrorUnsupported{Operation: "shared memory"}
+}
+
+// ListShared is not supported on Windows.
+func ListShared() ([]SharedInfo, error) {
+	return nil, &ErrorUnsupported{Operation: "shared memory"}
+}
@@ -0,0 +1,40 @@
+package mmap
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Pseudo handle of the current process.
+const currentProcess = ^uintptr(0)
+
+var (
+	modkernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procGetSystemInfo         = modkernel32.NewProc("GetSystemInfo")
+	procPrefetchVirtualMemory = modkernel32.NewProc("PrefetchVirtualMemory")
+	procQueryWorkingSetEx     = modkernel32.NewProc("K32QueryWorkingSetEx")
+	procVirtualProtect        = modkernel32.NewProc("VirtualProtect")
+)
+
+// SYSTEM_INFO structure.
+type systemInfo struct {
+	processorArchitecture     uint16
+	reserved                  uint16
+	pageSize                  uint32
+	minimumApplicationAddress uintptr
+	maximumApplicationAddress uintptr
+	activeProcessorMask       uintptr
+	numberOfProcessors        uint32
+	processorType             uint32
+	allocationGranularity     uint32
+	processorLevel            uint16
+	processorRevision         uint16
+}
+
+// allocationGranularity returns the granularity of the starting address at which the memory can be allocated.
+func allocationGranularity() uintptr {
+	var info systemInfo
+	procGetSystemInfo.Call(uintptr(unsafe.Pointer(&info)))
+	return uintptr(info.allocationGranularity)
+}
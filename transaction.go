@@ -7,8 +7,10 @@ import (
 
 // Transaction is a memory mapping transaction.
 // The transaction is not valid if the parent mapping is closed.
+// Offsets of the transaction started by the view are relative to the view.
 type Transaction struct {
 	mapping    *Mapping
+	origin     int64
 	offset     int64
 	highOffset int64
 	snapshot   []byte
@@ -31,20 +33,25 @@ func (m *Mapping) Begin(offset int64, length uintptr) (*Transaction, error) {
 	if length == 0 || highOffset > int64(len(m.memory)) {
 		return nil, &ErrorInvalidLength{Length: length}
 	}
+	if m.protection(offset, length)&ProtectionWrite == 0 {
+		return nil, &ErrorIllegalOperation{Operation: "transaction"}
+	}
 	tx := &Transaction{
 		mapping:    m,
 		offset:     offset,
 		highOffset: highOffset,
 		snapshot:   make([]byte, length),
 	}
-	copy(tx.snapshot, m.memory[offset:highOffset])
+	if err := m.safely(m.safe, func() { copy(tx.snapshot, m.memory[offset:highOffset]) }); err != nil {
+		return nil, err
+	}
 	runtime.SetFinalizer(tx, (*Transaction).Rollback)
 	return tx, nil
 }
 
 // Offset returns the starting offset of this transaction.
 func (tx *Transaction) Offset() int64 {
-	return tx.offset
+	return tx.offset - tx.origin
 }
 
 // Length returns the snapshot length in bytes.
@@ -52,14 +59,14 @@ func (tx *Transaction) Length() uintptr {
 	return uintptr(len(tx.snapshot))
 }
 
-// Read reads len(buf) bytes at given offset relatively to the parent mapping address from the snapshot.
+// Read reads len(buf) bytes at given offset relatively to the parent mapping or view address from the snapshot.
 // Implementation of io.ReaderAt.
 func (tx *Transaction) ReadAt(buf []byte, offset int64) (int, error) {
 	if tx.snapshot == nil {
 		return 0, &ErrorTransactionClosed{}
 	}
-	if offset < tx.offset || offset >= tx.highOffset {
-		return 0, &ErrorInvalidOffset{Offset: offset}
+	if offset += tx.origin; offset < tx.offset || offset >= tx.highOffset {
+		return 0, &ErrorInvalidOffset{Offset: offset - tx.origin}
 	}
 	n := copy(buf, tx.snapshot[offset-tx.offset:])
 	if n < len(buf) {
@@ -68,14 +75,14 @@ func (tx *Transaction) ReadAt(buf []byte, offset int64) (int, error) {
 	return n, nil
 }
 
-// Write writes len(buf) bytes at given offset relatively to the parent mapping address to the snapshot.
+// Write writes len(buf) bytes at given offset relatively to the parent mapping or view address to the snapshot.
 // Implementation of io.WriterAt.
 func (tx *Transaction) WriteAt(buf []byte, offset int64) (int, error) {
 	if tx.snapshot == nil {
 		return 0, &ErrorTransactionClosed{}
 	}
-	if offset < 0 || offset >= tx.highOffset {
-		return 0, &ErrorInvalidOffset{Offset: offset}
+	if offset += tx.origin; offset < tx.offset || offset >= tx.highOffset {
+		return 0, &ErrorInvalidOffset{Offset: offset - tx.origin}
 	}
 	n := copy(tx.snapshot[offset-tx.offset:], buf)
 	if n < len(buf) {
@@ -92,13 +99,34 @@ func (tx *Transaction) Commit() error {
 	if tx.mapping.memory == nil {
 		return &ErrorClosed{}
 	}
-	if n := copy(tx.mapping.memory[tx.offset:tx.highOffset], tx.snapshot); n < len(tx.snapshot) {
+	// The parent mapping may be shrunk since this transaction was started.
+	if tx.offset >= int64(len(tx.mapping.memory)) {
+		return &ErrorInvalidOffset{Offset: tx.Offset()}
+	}
+	if tx.mapping.protection(tx.offset, tx.mapping.span(tx.snapshot, tx.offset))&ProtectionWrite == 0 {
+		return &ErrorIllegalOperation{Operation: "commit"}
+	}
+	m, n := tx.mapping, 0
+	if err := m.safely(m.safe, func() { n = copy(m.memory[tx.offset:], tx.snapshot) }); err != nil {
+		return err
+	}
+	if n < len(tx.snapshot) {
 		return &ErrorPartialCommit{NumBytes: n}
 	}
 	tx.snapshot = nil
 	return nil
 }
 
+// CommitSync flushes the snapshot to the mapped memory, synchronizes only the committed range
+// with the underlying file using given flags, closes this transaction and frees all resources associated with it.
+func (tx *Transaction) CommitSync(flags SyncFlag) error {
+	length := uintptr(len(tx.snapshot))
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return tx.mapping.SyncRange(tx.offset, length, flags)
+}
+
 // Rollback closes this transaction and frees all resources associated with it.
 func (tx *Transaction) Rollback() error {
 	if tx.snapshot == nil {
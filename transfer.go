@@ -0,0 +1,18 @@
+package mmap
+
+import "encoding/binary"
+
+// transferHeader is the header of the mapping which is transferred between processes.
+// Header is followed by the metadata of given length.
+type transferHeader struct {
+	Offset     int64
+	Length     uint64
+	Mode       int32
+	MetaLength uint32
+}
+
+// transferHeaderSize is the size of the encoded transfer header in bytes.
+var transferHeaderSize = binary.Size(transferHeader{})
+
+// MaxTransferMetaLength is the maximum length of the metadata which is transferred with the mapping.
+const MaxTransferMetaLength = 64 << 10
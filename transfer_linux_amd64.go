@@ -0,0 +1,118 @@
+package mmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// SendMapping sends the file descriptor of given mapping with its range and mode
+// followed by given metadata over the stream Unix domain socket connection.
+// Receiving process gets an equivalent mapping of the same file using ReceiveMapping.
+// Anonymous mappings can not be sent.
+// Metadata must not be longer than MaxTransferMetaLength.
+func SendMapping(conn *net.UnixConn, m *Mapping, meta []byte) error {
+	if m.memory == nil {
+		return &ErrorClosed{}
+	}
+	if m.file == nil {
+		return &ErrorIllegalOperation{Operation: "send"}
+	}
+	if len(meta) > MaxTransferMetaLength {
+		return &ErrorInvalidLength{Length: uintptr(len(meta))}
+	}
+	header := transferHeader{
+		Offset:     m.offset + int64(m.address-m.alignedAddress),
+		Length:     uint64(len(m.memory)),
+		Mode:       int32(m.mode),
+		MetaLength: uint32(len(meta)),
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, transferHeaderSize+len(meta)))
+	if err := binary.Write(buf, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	buf.Write(meta)
+	n, _, err := conn.WriteMsgUnix(buf.Bytes(), syscall.UnixRights(int(m.file.Fd())), nil)
+	if err != nil {
+		return err
+	}
+	if n < buf.Len() {
+		_, err = conn.Write(buf.Bytes()[n:])
+	}
+	return err
+}
+
+// ReceiveMapping receives the mapping which is sent by SendMapping over the stream Unix domain socket connection
+// and returns it with the sent metadata.
+// The received file descriptor is owned by the returned mapping and is closed when the mapping is closed.
+// Metadata longer than MaxTransferMetaLength is not received, the connection should be closed in such case.
+func ReceiveMapping(conn *net.UnixConn) (*Mapping, []byte, error) {
+	buf := make([]byte, transferHeaderSize)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n == 0 && oobn == 0 {
+		return nil, nil, io.EOF
+	}
+	f, err := receivedFile(oob[:oobn])
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := io.ReadFull(conn, buf[n:]); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	var header transferHeader
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &header); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if header.MetaLength > MaxTransferMetaLength {
+		f.Close()
+		return nil, nil, &ErrorInvalidLength{Length: uintptr(header.MetaLength)}
+	}
+	meta := make([]byte, header.MetaLength)
+	if _, err := io.ReadFull(conn, meta); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	m, err := newMapping(f, &options{
+		mode:   Mode(header.Mode),
+		offset: header.Offset,
+		length: uintptr(header.Length),
+	})
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return m, meta, nil
+}
+
+// receivedFile returns the file of the descriptor which is received in given control message.
+func receivedFile(oob []byte) (*os.File, error) {
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, os.NewSyscallError("parse socket control message", err)
+	}
+	var fds []int
+	for _, message := range messages {
+		rights, err := syscall.ParseUnixRights(&message)
+		if err != nil {
+			continue
+		}
+		fds = append(fds, rights...)
+	}
+	if len(fds) != 1 {
+		for _, fd := range fds {
+			syscall.Close(fd)
+		}
+		return nil, &ErrorIllegalOperation{Operation: "receive"}
+	}
+	syscall.CloseOnExec(fds[0])
+	return os.NewFile(uintptr(fds[0]), ""), nil
+}
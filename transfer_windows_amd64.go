@@ -0,0 +1,13 @@
+package mmap
+
+import "net"
+
+// SendMapping is not supported on Windows.
+func SendMapping(conn *net.UnixConn, m *Mapping, meta []byte) error {
+	return &ErrorUnsupported{Operation: "send"}
+}
+
+// ReceiveMapping is not supported on Windows.
+func ReceiveMapping(conn *net.UnixConn) (*Mapping, []byte, error) {
+	return nil, nil, &ErrorUnsupported{Operation: "receive"}
+}
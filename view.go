@@ -0,0 +1,123 @@
+package mmap
+
+import "io"
+
+// View is a window of the mapped memory with offsets relative to its start.
+// The view can not access the memory outside of its window.
+// The view is not valid if the parent mapping is closed.
+type View struct {
+	mapping *Mapping
+	offset  int64
+	length  uintptr
+}
+
+// Slice returns a new view of the mapped memory starting from given offset and ends after given length.
+func (m *Mapping) Slice(offset int64, length uintptr) (*View, error) {
+	if m.memory == nil {
+		return nil, &ErrorClosed{}
+	}
+	if offset < 0 || offset >= int64(len(m.memory)) {
+		return nil, &ErrorInvalidOffset{Offset: offset}
+	}
+	if length == 0 || uint64(length) > uint64(int64(len(m.memory))-offset) {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	return &View{mapping: m, offset: offset, length: length}, nil
+}
+
+// Slice returns a new view of this view starting from given offset and ends after given length.
+func (v *View) Slice(offset int64, length uintptr) (*View, error) {
+	if offset < 0 || offset >= int64(v.length) {
+		return nil, &ErrorInvalidOffset{Offset: offset}
+	}
+	if length == 0 || uint64(length) > uint64(int64(v.length)-offset) {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	return &View{mapping: v.mapping, offset: v.offset + offset, length: length}, nil
+}
+
+// Mapping returns the parent mapping.
+func (v *View) Mapping() *Mapping {
+	return v.mapping
+}
+
+// Offset returns the starting offset of this view relatively to the parent mapping address.
+func (v *View) Offset() int64 {
+	return v.offset
+}
+
+// Length returns the view length in bytes.
+func (v *View) Length() uintptr {
+	return v.length
+}
+
+// Memory returns the byte slice which wraps the memory of this view
+// or nil if the parent mapping is closed or shrunk beyond the view.
+// Capacity of the slice is limited by the view length, so that it can not be extended by append.
+func (v *View) Memory() []byte {
+	memory := v.mapping.memory
+	high := v.offset + int64(v.length)
+	if high > int64(len(memory)) {
+		return nil
+	}
+	return memory[v.offset:high:high]
+}
+
+// Read reads len(buf) bytes at given offset relatively to the view start from the mapped memory.
+// Implementation of io.ReaderAt.
+func (v *View) ReadAt(buf []byte, offset int64) (int, error) {
+	if offset < 0 || offset >= int64(v.length) {
+		return 0, &ErrorInvalidOffset{Offset: offset}
+	}
+	short := false
+	if remaining := int64(v.length) - offset; int64(len(buf)) > remaining {
+		buf = buf[:remaining]
+		short = true
+	}
+	n, err := v.mapping.ReadAt(buf, v.offset+offset)
+	if err == nil && short {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Write writes len(buf) bytes at given offset relatively to the view start to the mapped memory.
+// Implementation of io.WriterAt.
+func (v *View) WriteAt(buf []byte, offset int64) (int, error) {
+	if offset < 0 || offset >= int64(v.length) {
+		return 0, &ErrorInvalidOffset{Offset: offset}
+	}
+	short := false
+	if remaining := int64(v.length) - offset; int64(len(buf)) > remaining {
+		buf = buf[:remaining]
+		short = true
+	}
+	n, err := v.mapping.WriteAt(buf, v.offset+offset)
+	if err == nil && short {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Begin starts a transaction of the view memory starting from given offset and ends after given length.
+// Offsets of the transaction are relative to the view start.
+// See Mapping.Begin for details.
+func (v *View) Begin(offset int64, length uintptr) (*Transaction, error) {
+	if offset < 0 || offset >= int64(v.length) {
+		return nil, &ErrorInvalidOffset{Offset: offset}
+	}
+	if length == 0 || uint64(length) > uint64(int64(v.length)-offset) {
+		return nil, &ErrorInvalidLength{Length: length}
+	}
+	tx, err := v.mapping.Begin(v.offset+offset, length)
+	if err != nil {
+		return nil, err
+	}
+	tx.origin = v.offset
+	return tx, nil
+}
+
+// Cursor returns a new cursor over the whole view.
+func (v *View) Cursor() *Cursor {
+	return NewCursor(v, 0, v.length)
+}